@@ -0,0 +1,33 @@
+// Package analysis derives summaries and rankings from benchmark results.
+package analysis
+
+import (
+	"bench-hashmaps/result"
+	"bench-hashmaps/stats"
+)
+
+// DefaultMaxCV is the coefficient of variation above which a cell is considered as noisy.
+const DefaultMaxCV = 0.05
+
+// Cell is the summary of all repetitions of one metric of one map in one sub-benchmark.
+type Cell struct {
+	result.Key
+	Metric string
+	stats.Summary
+}
+
+// Noisy reports whether the coefficient of variation of the cell exceeds maxCV.
+func (c *Cell) Noisy(maxCV float64) bool {
+	return c.N > 1 && c.CV() > maxCV
+}
+
+// Summarize summarizes the repetitions of the metric for each map and sub-benchmark.
+// The cells are returned in the order of their first appearance.
+func Summarize(run *result.Run, metric string) []Cell {
+	keys, groups := run.Group(metric)
+	cells := make([]Cell, len(keys))
+	for i, k := range keys {
+		cells[i] = Cell{Key: k, Metric: metric, Summary: stats.Summarize(groups[k])}
+	}
+	return cells
+}
@@ -0,0 +1,215 @@
+package analysis
+
+import (
+	"fmt"
+	"math"
+	"testing"
+
+	"bench-hashmaps/cpucache"
+	"bench-hashmaps/result"
+)
+
+func almostEqual(a, b float64) bool {
+	return math.Abs(a-b) <= 1e-9 || (math.IsNaN(a) && math.IsNaN(b))
+}
+
+// record returns a record of the map in the benchmark with the given metrics.
+func record(benchmark, mapName string, size int, metrics map[string]float64) result.Record {
+	keyType, scenario := result.SplitBenchmark(benchmark)
+	return result.Record{
+		Benchmark: benchmark,
+		Scenario:  scenario,
+		KeyType:   keyType,
+		Map:       mapName,
+		Size:      size,
+		Metrics:   metrics,
+	}
+}
+
+func TestSpeedup(t *testing.T) {
+	run := &result.Run{Records: []result.Record{
+		record("BenchmarkU64FullReads", "std", 1000, map[string]float64{"ns/op": 100, "Bytes": 1000}),
+		record("BenchmarkU64FullReads", "swiss", 1000, map[string]float64{"ns/op": 50, "Bytes": 2000}),
+		record("BenchmarkU64FullReads", "robin", 1000, map[string]float64{"ns/op": 200}),
+		record("BenchmarkU64FullReads", "std", 10000, map[string]float64{"ns/op": 100, "Bytes": 1000}),
+		record("BenchmarkU64FullReads", "swiss", 10000, map[string]float64{"ns/op": 25, "Bytes": 500}),
+		record("BenchmarkU64RandomFullInserts", "swiss", 1000, map[string]float64{"ns/op": 10}),
+	}}
+	tests := []struct {
+		benchmark, mapName string
+		size               int
+		want               Ratio
+	}{
+		{"BenchmarkU64FullReads", "std", 1000, Ratio{1, 1}},
+		{"BenchmarkU64FullReads", "swiss", 1000, Ratio{2, 2}},
+		{"BenchmarkU64FullReads", "robin", 1000, Ratio{0.5, math.NaN()}},
+		{"BenchmarkU64FullReads", "swiss", 10000, Ratio{4, 0.5}},
+		{"BenchmarkU64RandomFullInserts", "swiss", 1000, Ratio{math.NaN(), math.NaN()}},
+	}
+	table, err := Speedup(run, "std")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, tt := range tests {
+		got := table.Cell(tt.benchmark, tt.mapName, tt.size)
+		if !almostEqual(got.Speedup, tt.want.Speedup) || !almostEqual(got.Memory, tt.want.Memory) {
+			t.Errorf("%s %s-%d = %+v, want %+v", tt.benchmark, tt.mapName, tt.size, got, tt.want)
+		}
+	}
+	if want := []string{"std", "swiss", "robin"}; len(table.Maps) != len(want) || table.Maps[0] != "std" || table.Maps[2] != "robin" {
+		t.Errorf("maps = %v, want %v", table.Maps, want)
+	}
+	if got := table.GeoMean["swiss"]; !almostEqual(got.Speedup, math.Sqrt(8)) || !almostEqual(got.Memory, 1) {
+		t.Errorf("geometric mean of swiss = %+v, want {%v 1}", got, math.Sqrt(8))
+	}
+	if got := table.SizeGeoMean[10000]["swiss"]; !almostEqual(got.Speedup, 4) {
+		t.Errorf("geometric mean of swiss at 10000 = %+v, want speedup 4", got)
+	}
+	if _, err := Speedup(run, "hopscotch"); err == nil {
+		t.Error("missing baseline: expected an error")
+	}
+}
+
+func TestPareto(t *testing.T) {
+	point := func(mapName string, size int, time, bytes float64) result.Record {
+		return record("BenchmarkU64FullReads", mapName, size, map[string]float64{"ns/key": time, "B/key": bytes})
+	}
+	run := &result.Run{Records: []result.Record{
+		point("std", 1000, 20, 40),
+		point("swiss", 1000, 10, 30),
+		point("robin", 1000, 15, 20),
+		point("linear", 1000, 10, 30),
+		point("hopscotch", 1000, 30, 10),
+		point("std", 0, 1, 1),
+		point("std", 100, 5, 50),
+		record("BenchmarkU64FullReads", "swiss", 100, map[string]float64{"ns/key": 1}),
+	}}
+	tests := []struct {
+		mapName     string
+		optimal     bool
+		dominatedBy []string
+	}{
+		{"swiss", true, nil},
+		{"linear", true, nil},
+		{"robin", true, nil},
+		{"std", false, []string{"swiss", "robin", "linear"}},
+		{"hopscotch", true, nil},
+	}
+
+	frontiers := Pareto(run)
+	if len(frontiers) != 2 || frontiers[0].Size != 100 || frontiers[1].Size != 1000 {
+		t.Fatalf("got %d frontiers %+v, want the sizes 100 and 1000", len(frontiers), frontiers)
+	}
+	if points := frontiers[0].Points; len(points) != 1 || !points[0].Optimal {
+		t.Errorf("size 100: got %+v, want std as the only optimal point", points)
+	}
+	points := make(map[string]ParetoPoint)
+	for i, p := range frontiers[1].Points {
+		if i > 0 && p.Time < frontiers[1].Points[i-1].Time {
+			t.Errorf("points not sorted by time: %+v", frontiers[1].Points)
+		}
+		points[p.Map] = p
+	}
+	for _, tt := range tests {
+		p, ok := points[tt.mapName]
+		if !ok {
+			t.Errorf("%s: missing", tt.mapName)
+			continue
+		}
+		if p.Optimal != tt.optimal || fmt.Sprint(p.DominatedBy) != fmt.Sprint(tt.dominatedBy) {
+			t.Errorf("%s: optimal = %v dominated by %v, want %v and %v", tt.mapName, p.Optimal, p.DominatedBy, tt.optimal, tt.dominatedBy)
+		}
+	}
+}
+
+func TestRank(t *testing.T) {
+	timed := func(benchmark, mapName string, size int, time, bytes float64) result.Record {
+		return record(benchmark, mapName, size, map[string]float64{"ns/key": time, "B/key": bytes})
+	}
+	run := &result.Run{Records: []result.Record{
+		timed("BenchmarkU64FullReads", "std", 1000, 100, 64),
+		timed("BenchmarkU64FullReads", "swiss", 1000, 50, 32),
+		timed("BenchmarkU64FullReads", "robin", 1000, 40, 128),
+		timed("BenchmarkU64FullReads", "linear", 1000, 60, 64),
+		timed("BenchmarkU64FullReads", "std", 100000, 300, 64),
+		timed("BenchmarkU64RandomFullInserts", "std", 1000, 100, 64),
+		timed("BenchmarkU64RandomFullInserts", "swiss", 1000, 200, 32),
+		timed("BenchmarkU64RandomFullInserts", "robin", 1000, 100, 128),
+	}}
+	tests := []struct {
+		name    string
+		profile Profile
+		want    []string
+		exclude []string
+		wantErr bool
+	}{
+		{"reads", Profile{KeyType: "U64", Size: 1000, Weights: map[string]float64{"FullReads": 1}}, []string{"robin", "swiss", "linear", "std"}, nil, false},
+		{"inserts", Profile{KeyType: "U64", Size: 1000, Mix: map[string]float64{"insert": 1}}, []string{"robin", "std", "swiss"}, []string{"linear"}, false},
+		{"memory", Profile{KeyType: "U64", Size: 1000, Weights: map[string]float64{"FullReads": 1}, MemoryWeight: 1}, []string{"swiss", "linear", "robin", "std"}, nil, false},
+		{"selected maps", Profile{KeyType: "U64", Size: 1000, Mix: map[string]float64{"read": 1}, Maps: []string{"std", "hopscotch", "swiss"}}, []string{"swiss", "std"}, []string{"hopscotch"}, false},
+		{"no key type", Profile{Size: 1000, Weights: map[string]float64{"FullReads": 1}}, nil, nil, true},
+		{"no size", Profile{KeyType: "U64", Weights: map[string]float64{"FullReads": 1}}, nil, nil, true},
+		{"unknown scenario", Profile{KeyType: "U64", Size: 1000, Weights: map[string]float64{"RandomReads": 1}}, nil, nil, true},
+		{"unknown operation", Profile{KeyType: "U64", Size: 1000, Mix: map[string]float64{"scan": 1}}, nil, nil, true},
+		{"weights and mix", Profile{KeyType: "U64", Size: 1000, Weights: map[string]float64{"FullReads": 1}, Mix: map[string]float64{"read": 1}}, nil, nil, true},
+		{"negative weight", Profile{KeyType: "U64", Size: 1000, Weights: map[string]float64{"FullReads": -1}}, nil, nil, true},
+	}
+	for _, tt := range tests {
+		r, err := Rank(run, tt.profile)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("%s: error = %v, want error %v", tt.name, err, tt.wantErr)
+			continue
+		}
+		if err != nil {
+			continue
+		}
+		var got []string
+		for _, e := range r.Entries {
+			got = append(got, e.Map)
+		}
+		if fmt.Sprint(got) != fmt.Sprint(tt.want) || fmt.Sprint(r.Excluded) != fmt.Sprint(tt.exclude) {
+			t.Errorf("%s: ranked %v excluded %v, want %v and %v", tt.name, got, r.Excluded, tt.want, tt.exclude)
+		}
+		if r.Entries[0].Score < 1 || r.Confidence < 0 || r.Confidence > 1 {
+			t.Errorf("%s: score %v and confidence %v out of range", tt.name, r.Entries[0].Score, r.Confidence)
+		}
+	}
+}
+
+func TestCacheDrops(t *testing.T) {
+	point := func(mapName string, size int, table, time float64) result.Record {
+		return record("BenchmarkU64CacheSweep", mapName, size, map[string]float64{"ns/key": time, "table-bytes": table})
+	}
+	run := &result.Run{Records: []result.Record{
+		point("swiss", 10, 500, 1),
+		point("swiss", 20, 900, 1.1),
+		point("swiss", 30, 1300, 2),
+		point("swiss", 40, 1800, 2.5),
+		point("swiss", 50, 2500, 3),
+		point("std", 10, 200, 2),
+		point("std", 20, 3000, 4),
+		record("BenchmarkU64FullReads", "swiss", 10, map[string]float64{"ns/key": 1}),
+	}}
+	l1 := cpucache.Cache{Level: 1, Type: "Data", Size: 1000}
+	l2 := cpucache.Cache{Level: 2, Type: "Unified", Size: 100000}
+	tests := []struct {
+		mapName             string
+		before, after, drop int
+		slowdown            float64
+	}{
+		{"swiss", 20, 40, 30, 2.5 / 1.1},
+	}
+
+	drops := CacheDrops(run, []cpucache.Cache{l1, l2})
+	if len(drops) != len(tests) {
+		t.Fatalf("got %d drops %+v, want %d", len(drops), drops, len(tests))
+	}
+	for i, tt := range tests {
+		d := drops[i]
+		if d.Map != tt.mapName || d.Cache != l1 || d.Before.Size != tt.before || d.After.Size != tt.after ||
+			d.DropAt != tt.drop || !almostEqual(d.Slowdown, tt.slowdown) {
+			t.Errorf("drop %d = %+v, want %s from %d to %d with the drop at %d and slowdown %v",
+				i, d, tt.mapName, tt.before, tt.after, tt.drop, tt.slowdown)
+		}
+	}
+}
@@ -0,0 +1,99 @@
+package analysis
+
+import (
+	"sort"
+
+	"bench-hashmaps/cpucache"
+	"bench-hashmaps/result"
+)
+
+// SweepPoint is a size of a cache sweep with the mean retained heap of the table and time per lookup.
+type SweepPoint struct {
+	Size       int     `json:"size"`
+	TableBytes float64 `json:"tableBytes"`
+	Time       float64 `json:"time"`
+}
+
+// CacheDrop is the slowdown of the lookups of a map, when its table outgrows a cache level.
+type CacheDrop struct {
+	Benchmark string         `json:"benchmark"`
+	Map       string         `json:"map"`
+	Cache     cpucache.Cache `json:"cache"`
+	// Before is the largest table, which fits into the cache, After is the largest table up to twice the
+	// cache size.
+	Before SweepPoint `json:"before"`
+	After  SweepPoint `json:"after"`
+	// Slowdown is the time of After divided by the time of Before.
+	Slowdown float64 `json:"slowdown"`
+	// DropAt is the size after the steepest increase of the time between Before and After.
+	DropAt int `json:"dropAt"`
+}
+
+// CacheDrops finds the slowdown at each cache level for each map of the benchmarks, which report
+// the table-bytes metric like the cache sweeps. Levels, which are not straddled by the measured
+// tables, are skipped. The drops are sorted by benchmark, map and level.
+func CacheDrops(run *result.Run, caches []cpucache.Cache) []CacheDrop {
+	times := means(run, "ns/key")
+	tables := means(run, "table-bytes")
+
+	type series struct{ benchmark, mapName string }
+	sweeps := make(map[series][]SweepPoint)
+	seen := make(map[result.Key]bool)
+	for _, rec := range run.Records {
+		k := rec.Key()
+		t, okT := times[k]
+		tb, okB := tables[k]
+		if seen[k] || !okT || !okB {
+			continue
+		}
+		seen[k] = true
+		s := series{k.Benchmark, k.Map}
+		sweeps[s] = append(sweeps[s], SweepPoint{Size: k.Size, TableBytes: tb, Time: t})
+	}
+
+	var drops []CacheDrop
+	for s, points := range sweeps {
+		sort.Slice(points, func(i, j int) bool { return points[i].Size < points[j].Size })
+		for _, c := range caches {
+			before, after := -1, -1
+			for i, p := range points {
+				if p.TableBytes <= float64(c.Size) {
+					before = i
+				} else if p.TableBytes <= 2*float64(c.Size) {
+					after = i
+				}
+			}
+			if before < 0 || after < 0 || points[before].Time <= 0 {
+				continue
+			}
+			d := CacheDrop{
+				Benchmark: s.benchmark,
+				Map:       s.mapName,
+				Cache:     c,
+				Before:    points[before],
+				After:     points[after],
+				Slowdown:  points[after].Time / points[before].Time,
+				DropAt:    points[after].Size,
+			}
+			steepest := 0.0
+			for i := before + 1; i <= after; i++ {
+				if prev := points[i-1].Time; prev > 0 && points[i].Time/prev > steepest {
+					steepest = points[i].Time / prev
+					d.DropAt = points[i].Size
+				}
+			}
+			drops = append(drops, d)
+		}
+	}
+	sort.Slice(drops, func(i, j int) bool {
+		a, b := drops[i], drops[j]
+		if a.Benchmark != b.Benchmark {
+			return a.Benchmark < b.Benchmark
+		}
+		if a.Map != b.Map {
+			return a.Map < b.Map
+		}
+		return a.Cache.Level < b.Cache.Level
+	})
+	return drops
+}
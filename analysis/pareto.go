@@ -0,0 +1,85 @@
+package analysis
+
+import (
+	"sort"
+
+	"bench-hashmaps/result"
+)
+
+// ParetoPoint is the time and memory trade-off of one map.
+type ParetoPoint struct {
+	Map string `json:"map"`
+	// Time is the mean ns/key.
+	Time float64 `json:"time"`
+	// BytesPerEntry is the mean B/key metric.
+	BytesPerEntry float64 `json:"bytesPerEntry"`
+	// Optimal is true, if no other map is faster and smaller at the same time.
+	Optimal bool `json:"optimal"`
+	// DominatedBy contains the maps, which are better in both dimensions.
+	DominatedBy []string `json:"dominatedBy,omitempty"`
+}
+
+// Frontier contains the time and memory trade-off of all maps in one sub-benchmark.
+type Frontier struct {
+	Benchmark string        `json:"benchmark"`
+	Size      int           `json:"size"`
+	Points    []ParetoPoint `json:"points"`
+}
+
+// dominates reports whether a is not worse than b in both dimensions and better in at least one.
+func dominates(a, b *ParetoPoint) bool {
+	return a.Time <= b.Time && a.BytesPerEntry <= b.BytesPerEntry &&
+		(a.Time < b.Time || a.BytesPerEntry < b.BytesPerEntry)
+}
+
+// Pareto computes the Pareto frontier of time (ns/key) versus memory per entry (B/key)
+// for each benchmark and size. The frontiers are sorted by benchmark and size.
+func Pareto(run *result.Run) []Frontier {
+	times := means(run, "ns/key")
+	memory := means(run, "B/key")
+
+	type group struct {
+		benchmark string
+		size      int
+	}
+	frontiers := make(map[group]*Frontier)
+	seen := make(map[result.Key]bool)
+	for _, rec := range run.Records {
+		k := rec.Key()
+		t, okT := times[k]
+		m, okM := memory[k]
+		if seen[k] || !okT || !okM || k.Size == 0 {
+			continue
+		}
+		seen[k] = true
+		g := group{k.Benchmark, k.Size}
+		f, ok := frontiers[g]
+		if !ok {
+			f = &Frontier{Benchmark: k.Benchmark, Size: k.Size}
+			frontiers[g] = f
+		}
+		f.Points = append(f.Points, ParetoPoint{Map: k.Map, Time: t, BytesPerEntry: m})
+	}
+
+	out := make([]Frontier, 0, len(frontiers))
+	for _, f := range frontiers {
+		for i := range f.Points {
+			p := &f.Points[i]
+			for j := range f.Points {
+				if i != j && dominates(&f.Points[j], p) {
+					p.DominatedBy = append(p.DominatedBy, f.Points[j].Map)
+				}
+			}
+			p.Optimal = len(p.DominatedBy) == 0
+		}
+		sort.Slice(f.Points, func(i, j int) bool { return f.Points[i].Time < f.Points[j].Time })
+		out = append(out, *f)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Benchmark != out[j].Benchmark {
+			return out[i].Benchmark < out[j].Benchmark
+		}
+		return out[i].Size < out[j].Size
+	})
+	return out
+}
@@ -0,0 +1,332 @@
+package analysis
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"sort"
+	"strings"
+
+	"bench-hashmaps/result"
+)
+
+// Profile describes the expected workload of a map. The workload is given either as
+// weights over the benchmark scenarios or as mix of operation ratios.
+type Profile struct {
+	// KeyType is the key type prefix of the benchmarks, e.g. U64 or UUID.
+	KeyType string `json:"keyType"`
+	// Size is the expected number of entries.
+	Size int `json:"size"`
+	// Weights are the weights of the scenarios, e.g. {"FullReads": 0.8, "RandomFullInserts": 0.2}.
+	Weights map[string]float64 `json:"weights,omitempty"`
+	// Mix are the ratios of the operations read, miss, insert, delete and iterate.
+	Mix map[string]float64 `json:"mix,omitempty"`
+	// MemoryWeight is the weight of the memory per entry compared with the sum of all time weights.
+	MemoryWeight float64 `json:"memoryWeight,omitempty"`
+	// Maps limits the ranking to the given maps, all if empty.
+	Maps []string `json:"maps,omitempty"`
+}
+
+// operationScenarios maps the operations of a mix to the scenarios measuring them,
+// the first scenario available for the key type is used.
+var operationScenarios = map[string][]string{
+	"read":    {"FullReads", "RandomReads"},
+	"miss":    {"FullReadsMisses", "ReadsMisses"},
+	"insert":  {"RandomFullInserts", "RandomInserts"},
+	"delete":  {"RandomFullDeletes"},
+	"iterate": {"RandomFullIteration"},
+}
+
+// LoadProfile reads a JSON workload profile.
+func LoadProfile(path string) (Profile, error) {
+	var p Profile
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return p, err
+	}
+	if err := json.Unmarshal(data, &p); err != nil {
+		return p, fmt.Errorf("%s: %w", path, err)
+	}
+	return p, nil
+}
+
+// ScenarioScore is the result of a map in one weighted scenario.
+type ScenarioScore struct {
+	Benchmark string
+	Size      int
+	Weight    float64
+	Value     float64
+	// Relative is the value divided by the best value of all ranked maps.
+	Relative float64
+	// CV is the coefficient of variation of the value, 0 without repetitions.
+	CV float64
+}
+
+// RankEntry is the ranking of one map. Score is the weighted geometric mean of the
+// relative values, 1 means the map is the best in all scenarios.
+type RankEntry struct {
+	Map       string
+	Score     float64
+	Scenarios []ScenarioScore
+}
+
+// Ranking is the recommendation for a workload profile.
+type Ranking struct {
+	Profile Profile
+	Entries []RankEntry
+	// Confidence is between 0 and 1.
+	Confidence float64
+	Reasons    []string
+	// Excluded contains the maps, which have no results for all weighted scenarios.
+	Excluded []string
+}
+
+// ConfidenceLevel returns a textual representation of the confidence.
+func (r *Ranking) ConfidenceLevel() string {
+	switch {
+	case r.Confidence >= 0.7:
+		return "high"
+	case r.Confidence >= 0.4:
+		return "medium"
+	default:
+		return "low"
+	}
+}
+
+// weights returns the benchmark weights of the profile.
+func (p *Profile) weights(run *result.Run) (map[string]float64, error) {
+	if len(p.Weights) > 0 && len(p.Mix) > 0 {
+		return nil, fmt.Errorf("profile must contain either weights or mix, not both")
+	}
+	available := make(map[string]bool)
+	for _, rec := range run.Records {
+		if rec.KeyType == p.KeyType {
+			available[rec.Scenario] = true
+		}
+	}
+	w := make(map[string]float64)
+	for scenario, weight := range p.Weights {
+		if !available[scenario] {
+			return nil, fmt.Errorf("scenario %q not found for key type %q", scenario, p.KeyType)
+		}
+		w["Benchmark"+p.KeyType+scenario] += weight
+	}
+	for op, weight := range p.Mix {
+		candidates, ok := operationScenarios[op]
+		if !ok {
+			return nil, fmt.Errorf("unknown operation %q, valid are read, miss, insert, delete and iterate", op)
+		}
+		found := false
+		for _, scenario := range candidates {
+			if available[scenario] {
+				w["Benchmark"+p.KeyType+scenario] += weight
+				found = true
+				break
+			}
+		}
+		if !found {
+			return nil, fmt.Errorf("no scenario for operation %q and key type %q found", op, p.KeyType)
+		}
+	}
+	for b, weight := range w {
+		if weight < 0 {
+			return nil, fmt.Errorf("negative weight for %s", b)
+		} else if weight == 0 {
+			delete(w, b)
+		}
+	}
+	if len(w) == 0 {
+		return nil, fmt.Errorf("profile contains no weights")
+	}
+	return w, nil
+}
+
+// nearestSize returns the measured size of the benchmark with the smallest logarithmic distance to size.
+func nearestSize(run *result.Run, benchmark string, size int) int {
+	best, bestDist := 0, math.Inf(1)
+	for _, rec := range run.Records {
+		if rec.Benchmark != benchmark || rec.Size <= 0 {
+			continue
+		}
+		if d := math.Abs(math.Log(float64(rec.Size) / float64(size))); d < bestDist {
+			best, bestDist = rec.Size, d
+		}
+	}
+	return best
+}
+
+// Rank ranks the maps of the run for the workload profile.
+func Rank(run *result.Run, p Profile) (*Ranking, error) {
+	if p.KeyType == "" {
+		return nil, fmt.Errorf("profile contains no key type")
+	}
+	if p.Size <= 0 {
+		return nil, fmt.Errorf("profile contains no positive size")
+	}
+	weights, err := p.weights(run)
+	if err != nil {
+		return nil, err
+	}
+	benchmarks := make([]string, 0, len(weights))
+	totalWeight := 0.0
+	for b, w := range weights {
+		benchmarks = append(benchmarks, b)
+		totalWeight += w
+	}
+	sort.Strings(benchmarks)
+
+	times := Summarize(run, "ns/key")
+	memory := means(run, "B/key")
+	type cell struct {
+		mean, cv float64
+	}
+	cells := make(map[result.Key]cell)
+	for _, c := range times {
+		cells[c.Key] = cell{c.Mean, c.CV()}
+	}
+
+	candidates := p.Maps
+	if len(candidates) == 0 {
+		seen := make(map[string]bool)
+		for _, rec := range run.Records {
+			if !seen[rec.Map] {
+				seen[rec.Map] = true
+				candidates = append(candidates, rec.Map)
+			}
+		}
+	}
+
+	r := &Ranking{Profile: p}
+	entries := make(map[string]*RankEntry)
+	maxSizeDist := 0.0
+	for _, m := range candidates {
+		e := &RankEntry{Map: m}
+		complete := true
+		for _, b := range benchmarks {
+			size := nearestSize(run, b, p.Size)
+			c, ok := cells[result.Key{Benchmark: b, Map: m, Size: size}]
+			if !ok || c.mean <= 0 {
+				complete = false
+				break
+			}
+			maxSizeDist = math.Max(maxSizeDist, math.Abs(math.Log(float64(size)/float64(p.Size))))
+			e.Scenarios = append(e.Scenarios, ScenarioScore{Benchmark: b, Size: size, Weight: weights[b] / totalWeight, Value: c.mean, CV: c.cv})
+		}
+		if p.MemoryWeight > 0 && complete {
+			// the memory per entry is taken from the first weighted benchmark
+			b := benchmarks[0]
+			size := nearestSize(run, b, p.Size)
+			mem, ok := memory[result.Key{Benchmark: b, Map: m, Size: size}]
+			if !ok || mem <= 0 {
+				complete = false
+			} else {
+				e.Scenarios = append(e.Scenarios, ScenarioScore{Benchmark: "memory per entry", Size: size, Weight: p.MemoryWeight, Value: mem})
+			}
+		}
+		if !complete {
+			r.Excluded = append(r.Excluded, m)
+			continue
+		}
+		entries[m] = e
+	}
+	if len(entries) == 0 {
+		return nil, fmt.Errorf("no map has results for all weighted scenarios")
+	}
+
+	// normalize each scenario to the best map
+	for i := range entries[candidatesOf(entries)[0]].Scenarios {
+		best := math.Inf(1)
+		for _, e := range entries {
+			best = math.Min(best, e.Scenarios[i].Value)
+		}
+		for _, e := range entries {
+			e.Scenarios[i].Relative = e.Scenarios[i].Value / best
+		}
+	}
+	for _, m := range candidatesOf(entries) {
+		e := entries[m]
+		sumW, sumLog := 0.0, 0.0
+		for _, s := range e.Scenarios {
+			sumW += s.Weight
+			sumLog += s.Weight * math.Log(s.Relative)
+		}
+		e.Score = math.Exp(sumLog / sumW)
+		r.Entries = append(r.Entries, *e)
+	}
+	sort.SliceStable(r.Entries, func(i, j int) bool { return r.Entries[i].Score < r.Entries[j].Score })
+	r.estimateConfidence(maxSizeDist)
+	return r, nil
+}
+
+func candidatesOf(entries map[string]*RankEntry) []string {
+	names := make([]string, 0, len(entries))
+	for m := range entries {
+		names = append(names, m)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// estimateConfidence rates the recommendation by the margin between the best two maps
+// compared with the measurement noise, the distance between the profile size and the
+// measured sizes and the number of samples.
+func (r *Ranking) estimateConfidence(sizeDist float64) {
+	if len(r.Entries) < 2 {
+		r.Confidence = 1
+		r.Reasons = append(r.Reasons, "only one map has results for the profile")
+		return
+	}
+	first, second := &r.Entries[0], &r.Entries[1]
+	margin := second.Score/first.Score - 1
+
+	noise, samples := 0.0, 0
+	for _, e := range []*RankEntry{first, second} {
+		for _, s := range e.Scenarios {
+			if s.CV > 0 {
+				noise += s.CV
+				samples++
+			}
+		}
+	}
+	if samples > 0 {
+		noise /= float64(samples)
+		r.Reasons = append(r.Reasons, fmt.Sprintf("the mean coefficient of variation of the measurements is %.1f%%", noise*100))
+	} else {
+		noise = DefaultMaxCV
+		r.Reasons = append(r.Reasons, fmt.Sprintf("no repetitions available, the noise is assumed to be %.0f%%", noise*100))
+	}
+
+	r.Confidence = margin / (margin + 2*noise)
+	r.Reasons = append(r.Reasons, fmt.Sprintf("%s is %.1f%% better than %s", first.Map, margin*100, second.Map))
+	if sizeDist > math.Log(1.5) {
+		r.Confidence *= math.Exp(-(sizeDist - math.Log(1.5)))
+		r.Reasons = append(r.Reasons, fmt.Sprintf("the nearest measured size differs by factor %.1f from the expected size", math.Exp(sizeDist)))
+	}
+}
+
+// Justification explains why the best map was chosen compared with the runner-up.
+func (r *Ranking) Justification() string {
+	if len(r.Entries) == 0 {
+		return ""
+	}
+	first := &r.Entries[0]
+	if len(r.Entries) == 1 {
+		return fmt.Sprintf("%s is the only map with results for all weighted scenarios", first.Map)
+	}
+	second := &r.Entries[1]
+	var parts []string
+	for i, s := range first.Scenarios {
+		ratio := second.Scenarios[i].Value / s.Value
+		better, worse := "faster", "slower"
+		if s.Benchmark == "memory per entry" {
+			better, worse = "smaller", "larger"
+		}
+		if ratio < 1 {
+			ratio, better = 1/ratio, worse
+		}
+		parts = append(parts, fmt.Sprintf("%.2fx %s than %s in %s (weight %.2f)",
+			ratio, better, second.Map, strings.TrimPrefix(s.Benchmark, "Benchmark"), s.Weight))
+	}
+	return fmt.Sprintf("%s is %s", first.Map, strings.Join(parts, ", "))
+}
@@ -0,0 +1,157 @@
+package analysis
+
+import (
+	"fmt"
+	"math"
+	"sort"
+
+	"bench-hashmaps/result"
+	"bench-hashmaps/stats"
+)
+
+// DefaultBaseline is the map all other maps are compared with.
+const DefaultBaseline = "std"
+
+// Ratio compares a map with the baseline. Speedup is the baseline time divided by the time of
+// the map, Memory is the memory of the map divided by the baseline memory. Unknown ratios are NaN.
+type Ratio struct {
+	Speedup float64
+	Memory  float64
+}
+
+// SpeedupTable is the scenario × map matrix of ratios relative to a baseline map for each size.
+type SpeedupTable struct {
+	Baseline   string
+	Benchmarks []string
+	Maps       []string
+	Sizes      []int
+	Cells      map[result.Key]Ratio
+	// GeoMean contains the geometric mean of all ratios of a map.
+	GeoMean map[string]Ratio
+	// SizeGeoMean contains the geometric mean of all ratios of a map for one size.
+	SizeGeoMean map[int]map[string]Ratio
+}
+
+// Speedup normalizes the time (ns/op) and memory (Bytes) of every map to the baseline map.
+func Speedup(run *result.Run, baseline string) (*SpeedupTable, error) {
+	times := means(run, "ns/op")
+	memory := means(run, "Bytes")
+
+	t := &SpeedupTable{
+		Baseline:    baseline,
+		Cells:       make(map[result.Key]Ratio),
+		GeoMean:     make(map[string]Ratio),
+		SizeGeoMean: make(map[int]map[string]Ratio),
+	}
+	benchmarks := make(map[string]bool)
+	maps := make(map[string]bool)
+	sizes := make(map[int]bool)
+	speedups := make(map[string][]float64)
+	memRatios := make(map[string][]float64)
+	sizeSpeedups := make(map[int]map[string][]float64)
+	sizeMemRatios := make(map[int]map[string][]float64)
+
+	for _, rec := range run.Records {
+		k := rec.Key()
+		if _, done := t.Cells[k]; done {
+			continue
+		}
+		base := result.Key{Benchmark: k.Benchmark, Map: baseline, Size: k.Size}
+		baseTime, ok := times[base]
+		if !ok {
+			continue
+		}
+		r := Ratio{Speedup: math.NaN(), Memory: math.NaN()}
+		if tm, ok := times[k]; ok && tm > 0 {
+			r.Speedup = baseTime / tm
+		}
+		if m, ok := memory[k]; ok {
+			if bm := memory[base]; bm > 0 {
+				r.Memory = m / bm
+			}
+		}
+		t.Cells[k] = r
+		benchmarks[k.Benchmark] = true
+		maps[k.Map] = true
+		sizes[k.Size] = true
+		if sizeSpeedups[k.Size] == nil {
+			sizeSpeedups[k.Size] = make(map[string][]float64)
+			sizeMemRatios[k.Size] = make(map[string][]float64)
+		}
+		if !math.IsNaN(r.Speedup) {
+			speedups[k.Map] = append(speedups[k.Map], r.Speedup)
+			sizeSpeedups[k.Size][k.Map] = append(sizeSpeedups[k.Size][k.Map], r.Speedup)
+		}
+		if !math.IsNaN(r.Memory) && r.Memory > 0 {
+			memRatios[k.Map] = append(memRatios[k.Map], r.Memory)
+			sizeMemRatios[k.Size][k.Map] = append(sizeMemRatios[k.Size][k.Map], r.Memory)
+		}
+	}
+	if len(t.Cells) == 0 {
+		return nil, fmt.Errorf("baseline map %q not found", baseline)
+	}
+
+	t.Benchmarks = sortedKeys(benchmarks)
+	t.Maps = orderMaps(run, maps, baseline)
+	for s := range sizes {
+		t.Sizes = append(t.Sizes, s)
+	}
+	sort.Ints(t.Sizes)
+	for _, m := range t.Maps {
+		t.GeoMean[m] = Ratio{Speedup: geoMean(speedups[m]), Memory: geoMean(memRatios[m])}
+	}
+	for _, s := range t.Sizes {
+		t.SizeGeoMean[s] = make(map[string]Ratio)
+		for _, m := range t.Maps {
+			t.SizeGeoMean[s][m] = Ratio{Speedup: geoMean(sizeSpeedups[s][m]), Memory: geoMean(sizeMemRatios[s][m])}
+		}
+	}
+	return t, nil
+}
+
+// Cell returns the ratio of the map in the benchmark for the given size.
+func (t *SpeedupTable) Cell(benchmark, mapName string, size int) Ratio {
+	r, ok := t.Cells[result.Key{Benchmark: benchmark, Map: mapName, Size: size}]
+	if !ok {
+		return Ratio{Speedup: math.NaN(), Memory: math.NaN()}
+	}
+	return r
+}
+
+// means returns the mean of the metric for each key.
+func means(run *result.Run, metric string) map[result.Key]float64 {
+	m := make(map[result.Key]float64)
+	for _, c := range Summarize(run, metric) {
+		m[c.Key] = c.Mean
+	}
+	return m
+}
+
+func geoMean(values []float64) float64 {
+	if len(values) == 0 {
+		return math.NaN()
+	}
+	return stats.GeoMean(values)
+}
+
+func sortedKeys(m map[string]bool) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// orderMaps returns the maps in the order of their first appearance with the baseline first.
+func orderMaps(run *result.Run, maps map[string]bool, baseline string) []string {
+	ordered := []string{baseline}
+	seen := map[string]bool{baseline: true}
+	for _, rec := range run.Records {
+		if maps[rec.Map] && !seen[rec.Map] {
+			seen[rec.Map] = true
+			ordered = append(ordered, rec.Map)
+		}
+	}
+	return ordered
+}
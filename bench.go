@@ -3,19 +3,19 @@ package bench_test
 import (
 	"fmt"
 	"math/rand"
-	"os"
 	"reflect"
 	"runtime"
-	"strconv"
 	"strings"
 	"sync"
 	"testing"
-	"time"
 	"unsafe"
 
 	"github.com/google/uuid"
 	"golang.org/x/exp/constraints"
 
+	"bench-hashmaps/dataset"
+	"bench-hashmaps/hashfn"
+
 	"github.com/EinfachAndy/hashmaps"
 	cornelk "github.com/cornelk/hashmap"
 	"github.com/dolthub/swiss"
@@ -32,26 +32,9 @@ type ordered interface {
 		~string
 }
 
-func init() {
-	rand.Seed(time.Now().UnixNano())
-}
-
+// getRanges returns the benchmarked sizes (n) of the configuration.
 func getRanges() []int {
-	r := os.Getenv("RANGES")
-	if r == "" {
-		r = "50000 100000 200000 400000 600000 800000 1000000 1200000 1400000 1600000 1800000 2000000 2200000 2400000 2600000 2800000 3000000"
-	}
-	rangesStr := strings.Split(r, " ")
-	rangesInt := make([]int, len(rangesStr))
-
-	for i := range rangesStr {
-		var err error
-		rangesInt[i], err = strconv.Atoi(rangesStr[i])
-		if err != nil {
-			panic(err)
-		}
-	}
-	return rangesInt
+	return cfg.Sizes
 }
 
 func handleElem[K comparable, V any](key K, val V) bool {
@@ -63,12 +46,34 @@ func handleElem3(key any, value any) bool {
 	return false
 }
 
+// knownMaps contains all map names supported by createMap.
+var knownMaps = append([]string{
+	"std", "robin", "robinLowLoad", "unordered", "swiss", "generic",
+	"flat", "hopscotch", "hopscotchLowLoad", "cornelk", "sync",
+}, genericVariants()...)
+
+// genericVariants returns the names of the generic map with each hash function of package hashfn.
+func genericVariants() []string {
+	var names []string
+	for _, name := range hashfn.Names() {
+		if name != "generic" {
+			names = append(names, "generic_"+name)
+		}
+	}
+	return names
+}
+
+// mapOrder shuffles the maps with its own generator, so the order does not change the generated keys.
+var mapOrder *rand.Rand
+
+// getMapNames returns the benchmarked maps of the configuration. The order is randomized
+// on each call to avoid a systematic bias between the maps, unless shuffling is disabled.
 func getMapNames() []string {
-	m := os.Getenv("MAPS")
-	if m == "" {
-		m = "std robin robinLowLoad unordered swiss generic flat hopscotch hopscotchLowLoad"
+	names := append([]string(nil), cfg.Maps...)
+	if *cfg.Shuffle {
+		mapOrder.Shuffle(len(names), func(i, j int) { names[i], names[j] = names[j], names[i] })
 	}
-	return strings.Split(m, " ")
+	return names
 }
 
 func createMap[K ordered, V any](n int, mapName string) hashmaps.IHashMap[K, V] {
@@ -215,24 +220,7 @@ func createMap[K ordered, V any](n int, mapName string) hashmaps.IHashMap[K, V]
 		default:
 			panic("type not supported")
 		}
-		return hashmaps.IHashMap[K, V]{
-			Get: m.Get,
-			Put: func(k K, v V) bool {
-				m.Put(k, v)
-				return true
-			},
-			Remove: func(k K) bool {
-				m.Remove(k)
-				return true
-			},
-			Size: m.Size,
-			Each: func(callback func(key K, val V) bool) {
-				m.Each(handleElem2[K, V])
-			},
-			Load: func() float32 {
-				return -1.0 //unknown
-			},
-		}
+		return wrapGeneric(m)
 	case "cornelk":
 		// very slow
 		m := cornelk.New[K, V]()
@@ -252,7 +240,11 @@ func createMap[K ordered, V any](n int, mapName string) hashmaps.IHashMap[K, V]
 		return hashmaps.IHashMap[K, V]{
 			Get: func(k K) (V, bool) {
 				v, ok := m.Load(k)
-				return v.(V), ok
+				if !ok {
+					var zero V
+					return zero, false
+				}
+				return v.(V), true
 			},
 			Put: func(k K, v V) bool {
 				m.Store(k, v)
@@ -271,11 +263,102 @@ func createMap[K ordered, V any](n int, mapName string) hashmaps.IHashMap[K, V]
 		}
 
 	default:
+		// the generic map with another hash function, e.g. generic_wyhash
+		if h, ok := hashfn.Get(strings.TrimPrefix(mapName, "generic_")); ok && strings.HasPrefix(mapName, "generic_") {
+			return wrapGeneric(gmap.New[K, V](uint64(n), g.Equals[K], hashfn.For[K](h)))
+		}
 		panic(fmt.Sprintln("unknown map:", mapName))
 	}
 }
 
+func wrapGeneric[K ordered, V any](m *gmap.Map[K, V]) hashmaps.IHashMap[K, V] {
+	return hashmaps.IHashMap[K, V]{
+		Get: m.Get,
+		Put: func(k K, v V) bool {
+			m.Put(k, v)
+			return true
+		},
+		Remove: func(k K) bool {
+			m.Remove(k)
+			return true
+		},
+		Size: m.Size,
+		Each: func(callback func(key K, val V) bool) {
+			m.Each(handleElem2[K, V])
+		},
+		Load: func() float32 {
+			return -1.0 //unknown
+		},
+	}
+}
+
+// keySet is the key dataset of the configuration, which replaces the generated keys, nil if not configured.
+var keySet *dataset.Set
+
+// datasetSupports reports whether the key type (U32, U64 or UUID) can be used with the dataset.
+func datasetSupports(keyType string) bool {
+	return keySet.IsInt() == (keyType != "UUID")
+}
+
+// datasetInts returns n distinct keys of the dataset converted to V. Without exclude, the keys are taken
+// from the front of the dataset, otherwise from the back, skipping the excluded keys. Like the generators,
+// the key 0 is never used.
+func datasetInts[V constraints.Integer](n int, exclude []V) []V {
+	values := make(map[V]bool, n+len(exclude))
+	values[0] = true
+	for _, x := range exclude {
+		values[x] = true
+	}
+	arr := make([]V, 0, n)
+	for i := range keySet.Ints {
+		j := i
+		if exclude != nil {
+			j = len(keySet.Ints) - 1 - i
+		}
+		x := V(keySet.Ints[j])
+		if !values[x] {
+			values[x] = true
+			arr = append(arr, x)
+			if len(arr) == n {
+				return arr
+			}
+		}
+	}
+	var x V
+	panic(fmt.Sprintf("dataset %s contains only %d distinct keys of type %T, but %d are required", keySet.Path, len(arr), x, n))
+}
+
+// datasetStrings is the string version of datasetInts.
+func datasetStrings(n int, exclude []string) []string {
+	values := make(map[string]bool, n+len(exclude))
+	for _, x := range exclude {
+		values[x] = true
+	}
+	arr := make([]string, 0, n)
+	for i := range keySet.Strings {
+		j := i
+		if exclude != nil {
+			j = len(keySet.Strings) - 1 - i
+		}
+		x := keySet.Strings[j]
+		if !values[x] {
+			values[x] = true
+			arr = append(arr, x)
+			if len(arr) == n {
+				return arr
+			}
+		}
+	}
+	panic(fmt.Sprintf("dataset %s contains only %d distinct keys, but %d are required", keySet.Path, len(arr), n))
+}
+
 func genRandIntArray[V constraints.Integer](n int) []V {
+	if keySet != nil {
+		return datasetInts[V](n, nil)
+	}
+	if usePattern() {
+		return genPatternInts[V](n, nil)
+	}
 	values := make(map[V]bool, n)
 	values[0] = true
 	arr := make([]V, n)
@@ -292,16 +375,27 @@ func genRandIntArray[V constraints.Integer](n int) []V {
 }
 
 func genShuffledIntArray[V constraints.Integer](n int) []V {
+	if keySet != nil {
+		return datasetInts[V](n, nil)
+	}
+	if usePattern() {
+		return genPatternInts[V](n, nil)
+	}
 	arr := make([]V, n)
 	for i := range arr {
 		arr[i] = V(i + 1)
 	}
-	rand.Seed(time.Now().UnixNano())
 	rand.Shuffle(len(arr), func(i, j int) { arr[i], arr[j] = arr[j], arr[i] })
 	return arr
 }
 
 func genDifferentRandIntArray[V constraints.Integer](in []V) []V {
+	if keySet != nil {
+		return datasetInts(len(in), in)
+	}
+	if usePattern() {
+		return genPatternInts(len(in), in)
+	}
 	out := make([]V, len(in))
 	values := make(map[V]bool, len(in))
 	for _, x := range in {
@@ -322,6 +416,9 @@ func genDifferentRandIntArray[V constraints.Integer](in []V) []V {
 }
 
 func genUUIDArray(n int) []string {
+	if keySet != nil {
+		return datasetStrings(n, nil)
+	}
 	arr := make([]string, n)
 	for i := range arr {
 		arr[i] = uuid.NewString()
@@ -329,6 +426,16 @@ func genUUIDArray(n int) []string {
 	return arr
 }
 
+// genDifferentUUIDArray returns len(in) keys, which are not contained in in.
+func genDifferentUUIDArray(in []string) []string {
+	if keySet != nil {
+		return datasetStrings(len(in), in)
+	}
+	return genUUIDArray(len(in))
+}
+
+// report adds the custom metrics to the benchmark result. Besides the absolute values of a whole pass
+// over n keys, the time, throughput and memory are normalized per key to be comparable between the ranges.
 func report(b *testing.B, n int, load float32) {
 	b.ReportAllocs()
 	b.ReportMetric(float64(n), "N-runs")
@@ -336,4 +443,12 @@ func report(b *testing.B, n int, load float32) {
 	var mem runtime.MemStats
 	runtime.ReadMemStats(&mem)
 	b.ReportMetric(float64(mem.Alloc), "Bytes")
+	if n > 0 && b.N > 0 {
+		nsPerOp := float64(b.Elapsed().Nanoseconds()) / float64(b.N)
+		b.ReportMetric(nsPerOp/float64(n), "ns/key")
+		if nsPerOp > 0 {
+			b.ReportMetric(float64(n)/nsPerOp*1e3, "Mops/s")
+		}
+		b.ReportMetric(float64(mem.Alloc)/float64(n), "B/key")
+	}
 }
@@ -0,0 +1,192 @@
+package bench_test
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"runtime"
+	"sort"
+	"testing"
+
+	"bench-hashmaps/cpucache"
+)
+
+const (
+	// cacheProbe is the number of entries of the first estimate of the bytes per entry of a map.
+	cacheProbe = 1 << 16
+	// cacheLookups is the minimal number of lookups per iteration, the small tables are read repeatedly.
+	cacheLookups = 1 << 20
+	// cacheMinSize and cacheMaxSize limit the sizes of the sweep.
+	cacheMinSize = 64
+	cacheMaxSize = 1 << 24
+)
+
+// cacheFactors are the sizes of the sweep relative to the number of entries, which fill a cache level.
+// They are spaced by √2, so each boundary is straddled by two sizes below and two sizes above.
+var cacheFactors = []float64{0.5, 0.71, 1, 1.41, 2}
+
+func BenchmarkU32CacheSweep(b *testing.B) {
+	runCacheSweep(b, genRandIntArray[uint32])
+}
+
+func BenchmarkU64CacheSweep(b *testing.B) {
+	runCacheSweep(b, genRandIntArray[uint64])
+}
+
+func BenchmarkUUIDCacheSweep(b *testing.B) {
+	runCacheSweep(b, genUUIDArray)
+}
+
+// tableBytes returns the retained heap of a map with the given keys.
+func tableBytes[K ordered](mapName string, keys []K) int64 {
+	var mem runtime.MemStats
+	runtime.GC()
+	runtime.ReadMemStats(&mem)
+	base := int64(mem.HeapAlloc)
+	m := createMap[K, uint64](0, mapName)
+	for _, k := range keys {
+		m.Put(k, 1)
+	}
+	runtime.GC()
+	runtime.ReadMemStats(&mem)
+	runtime.KeepAlive(m)
+	return int64(mem.HeapAlloc) - base
+}
+
+// cacheSweepSizes returns the sorted distinct sizes, which straddle the cache levels for the given
+// entries per level. Sizes closer than 5% are merged.
+func cacheSweepSizes(entries []float64) []int {
+	var sizes []int
+	for _, e := range entries {
+		for _, f := range cacheFactors {
+			n := int(math.Round(e * f))
+			if n >= cacheMinSize && n <= cacheMaxSize {
+				sizes = append(sizes, n)
+			}
+		}
+	}
+	sort.Ints(sizes)
+	merged := sizes[:0]
+	for _, n := range sizes {
+		if len(merged) == 0 || float64(n) > 1.05*float64(merged[len(merged)-1]) {
+			merged = append(merged, n)
+		}
+	}
+	return merged
+}
+
+// cacheEntries returns the number of entries of the map, which fill each cache level. The bytes per entry
+// are first estimated with cacheProbe entries and then measured at the estimated boundary of each level,
+// because they depend on the load factor of the table.
+func cacheEntries[K ordered](mapName string, pool []K) []float64 {
+	probe := pool
+	if len(probe) > cacheProbe {
+		probe = probe[:cacheProbe]
+	}
+	estimate := float64(tableBytes(mapName, probe)) / float64(len(probe))
+	entries := make([]float64, len(caches))
+	for i, c := range caches {
+		n := int(float64(c.Size) / estimate)
+		if n < cacheMinSize || n > len(pool) {
+			entries[i] = float64(c.Size) / estimate
+			continue
+		}
+		entries[i] = float64(c.Size) / (float64(tableBytes(mapName, pool[:n])) / float64(n))
+	}
+	return entries
+}
+
+// runCacheSweep looks up all keys in random order like the FullReads scenarios, for sizes which straddle
+// the boundaries of the detected or configured cache levels (cfg.CacheSizes). The sizes depend on the bytes
+// per entry of each map. Besides the default metrics, where ns/key and Mops/s are per lookup, it reports:
+//   - table-bytes: the retained heap of the map, which is compared with the cache sizes
+//   - B/entry: the table bytes per entry
+func runCacheSweep[K ordered](b *testing.B, gen func(int) []K) {
+	if len(caches) == 0 {
+		b.Skip("no caches detected, set them with -cache-sizes")
+	}
+	if keySet != nil {
+		b.Skip("the cache sweep requires more keys than a dataset provides")
+	}
+	// the bytes per entry of each map are measured with the keys filling the last level for the estimate
+	// of the probe, the sizes of all maps are taken from one pool of keys with the largest size
+	llc, _ := cpucache.LLC(caches)
+	mapNames := getMapNames()
+	pool := gen(cacheProbe)
+	measure := cacheProbe
+	for _, mapName := range mapNames {
+		bpe := float64(tableBytes(mapName, pool)) / cacheProbe
+		if n := int(float64(llc.Size) / bpe); n > measure {
+			measure = n
+		}
+	}
+	if measure > cacheMaxSize {
+		measure = cacheMaxSize
+	}
+	pool = gen(measure)
+	sizes := make(map[string][]int, len(mapNames))
+	largest := 0
+	for _, mapName := range mapNames {
+		sizes[mapName] = cacheSweepSizes(cacheEntries(mapName, pool))
+		if n := sizes[mapName]; len(n) > 0 && n[len(n)-1] > largest {
+			largest = n[len(n)-1]
+		}
+	}
+	if largest > len(pool) {
+		pool = gen(largest)
+	}
+
+	// the keys are shuffled by a generator of the benchmark, so the order does not depend on the earlier
+	// benchmarks, and in a copy, so the maps are not measured with the order of the previous map
+	rng := rand.New(rand.NewSource(*cfg.Seed))
+	for _, mapName := range mapNames {
+		for _, n := range sizes[mapName] {
+			arr := append([]K(nil), pool[:n]...)
+			rounds := 1
+			if n < cacheLookups {
+				rounds = cacheLookups / n
+			}
+			b.Run(fmt.Sprintf("%s-%d", mapName, n), func(b *testing.B) {
+				var table int64
+				var mem runtime.MemStats
+				load := float32(-1.0)
+				for i := 0; i < b.N; i++ {
+					b.StopTimer()
+					runtime.GC()
+					runtime.ReadMemStats(&mem)
+					base := int64(mem.HeapAlloc)
+
+					m := createMap[K, uint64](0, mapName)
+					for j := range arr {
+						m.Put(arr[j], 1)
+					}
+					runtime.GC()
+					runtime.ReadMemStats(&mem)
+					table += int64(mem.HeapAlloc) - base
+					rng.Shuffle(len(arr), func(i, j int) { arr[i], arr[j] = arr[j], arr[i] })
+
+					b.StartTimer()
+					for r := 0; r < rounds; r++ {
+						for j := range arr {
+							if _, found := m.Get(arr[j]); !found {
+								b.Fatal("inserted key not found")
+							}
+						}
+					}
+					b.StopTimer()
+
+					load = m.Load()
+				}
+				report(b, n, load)
+				lookups := float64(b.N * rounds * n)
+				nsPerKey := float64(b.Elapsed().Nanoseconds()) / lookups
+				b.ReportMetric(nsPerKey, "ns/key")
+				if nsPerKey > 0 {
+					b.ReportMetric(1e3/nsPerKey, "Mops/s")
+				}
+				b.ReportMetric(float64(table)/float64(b.N), "table-bytes")
+				b.ReportMetric(float64(table)/float64(b.N*n), "B/entry")
+			})
+		}
+	}
+}
@@ -0,0 +1,76 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"text/tabwriter"
+
+	"bench-hashmaps/analysis"
+	"bench-hashmaps/cpucache"
+	"bench-hashmaps/result"
+)
+
+func runCacheDrops(args []string) error {
+	fs := flag.NewFlagSet("cache-drops", flag.ContinueOnError)
+	sizes := fs.String("cache-sizes", "", "cache sizes by level, e.g. 48K,2M,105M (default: caches of the JSON metadata)")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "usage: benchtool cache-drops [flags] file.(out|json)")
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 1 {
+		fs.Usage()
+		return flag.ErrHelp
+	}
+
+	run, err := result.Load(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	caches, err := runCaches(run, *sizes)
+	if err != nil {
+		return err
+	}
+	if len(caches) == 0 {
+		return fmt.Errorf("%s: no caches in the metadata, set them with -cache-sizes", fs.Arg(0))
+	}
+	drops := analysis.CacheDrops(run, caches)
+	if len(drops) == 0 {
+		return fmt.Errorf("%s: no cache sweep straddles the caches, run e.g. BenchmarkU64CacheSweep", fs.Arg(0))
+	}
+	return printCacheDrops(os.Stdout, drops)
+}
+
+// runCaches returns the cache sizes of the flag or otherwise the caches of the run metadata.
+func runCaches(run *result.Run, sizes string) ([]cpucache.Cache, error) {
+	if sizes != "" {
+		return cpucache.ParseList(sizes)
+	}
+	return run.Metadata.Caches, nil
+}
+
+func printCacheDrops(w io.Writer, drops []analysis.CacheDrop) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintln(tw, "benchmark\tmap\tcache\tfits n\ttable\tns/key\texceeds n\ttable\tns/key\tslowdown\tdrop at n\t")
+	for _, d := range drops {
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%d\t%s\t%.1f\t%d\t%s\t%.1f\t%.2fx\t%d\t\n",
+			d.Benchmark, d.Map, d.Cache, d.Before.Size, formatBytes(d.Before.TableBytes), d.Before.Time,
+			d.After.Size, formatBytes(d.After.TableBytes), d.After.Time, d.Slowdown, d.DropAt)
+	}
+	return tw.Flush()
+}
+
+// formatBytes formats a size in bytes with a binary unit.
+func formatBytes(b float64) string {
+	switch {
+	case b >= 1<<20:
+		return fmt.Sprintf("%.1fM", b/(1<<20))
+	case b >= 1<<10:
+		return fmt.Sprintf("%.1fK", b/(1<<10))
+	}
+	return fmt.Sprintf("%.0f", b)
+}
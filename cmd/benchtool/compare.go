@@ -0,0 +1,107 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"bench-hashmaps/compare"
+	"bench-hashmaps/result"
+	"bench-hashmaps/stats"
+)
+
+func runCompare(args []string) error {
+	fs := flag.NewFlagSet("compare", flag.ContinueOnError)
+	metrics := fs.String("metrics", strings.Join(compare.DefaultMetrics, ","), "comma separated list of compared metrics")
+	onlySignificant := fs.Bool("significant", false, "print only statistically significant differences")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "usage: benchtool compare [flags] old.(out|json) new.(out|json)")
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 2 {
+		fs.Usage()
+		return flag.ErrHelp
+	}
+
+	old, err := result.Load(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	new, err := result.Load(fs.Arg(1))
+	if err != nil {
+		return err
+	}
+	deltas := compare.Compare(old, new, strings.Split(*metrics, ","))
+	if len(deltas) == 0 {
+		return fmt.Errorf("no common sub-benchmarks found")
+	}
+	return printDeltas(os.Stdout, deltas, *onlySignificant)
+}
+
+func printDeltas(w io.Writer, deltas []compare.Delta, onlySignificant bool) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	metric := ""
+	fewSamples := false
+	for i := range deltas {
+		d := &deltas[i]
+		if onlySignificant && !d.Significant() {
+			continue
+		}
+		fewSamples = fewSamples || d.Old.N < 4 || d.New.N < 4
+		if d.Metric != metric {
+			if metric != "" {
+				fmt.Fprintln(tw)
+			}
+			metric = d.Metric
+			fmt.Fprintf(tw, "benchmark\tmap\tsize\told %s\tnew %s\tdelta\t\n", metric, metric)
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%d\t%s\t%s\t%s\t\n",
+			strings.TrimPrefix(d.Benchmark, "Benchmark"), d.Map, d.Size,
+			formatSummary(d.Old), formatSummary(d.New), formatChange(d))
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+	if fewSamples {
+		_, err := fmt.Fprintln(w, "\nnote: a significant difference needs at least 4 samples per run (go test -count)")
+		return err
+	}
+	return nil
+}
+
+// formatSummary prints the mean and the relative 95% confidence interval like benchstat.
+func formatSummary(s stats.Summary) string {
+	if s.N < 2 || s.Mean == 0 {
+		return formatValue(s.Mean)
+	}
+	return fmt.Sprintf("%s ± %.0f%%", formatValue(s.Mean), s.CI/math.Abs(s.Mean)*100)
+}
+
+// formatValue prints the value with four significant digits and a SI prefix.
+func formatValue(v float64) string {
+	prefixes := []struct {
+		scale  float64
+		prefix string
+	}{{1e12, "T"}, {1e9, "G"}, {1e6, "M"}, {1e3, "k"}}
+	for _, p := range prefixes {
+		if math.Abs(v) >= p.scale {
+			return fmt.Sprintf("%.4g%s", v/p.scale, p.prefix)
+		}
+	}
+	return fmt.Sprintf("%.4g", v)
+}
+
+func formatChange(d *compare.Delta) string {
+	n := fmt.Sprintf("n=%d+%d", d.Old.N, d.New.N)
+	if !d.Significant() {
+		return fmt.Sprintf("~ %+.2f%% (p=%.3f %s)", d.Change, d.P, n)
+	}
+	return fmt.Sprintf("%+.2f%% (p=%.3f %s)", d.Change, d.P, n)
+}
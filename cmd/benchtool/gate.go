@@ -0,0 +1,77 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"bench-hashmaps/compare"
+	"bench-hashmaps/result"
+)
+
+func runGate(args []string) error {
+	fs := flag.NewFlagSet("gate", flag.ContinueOnError)
+	baselinePath := fs.String("baseline", "", "baseline result file (required)")
+	configPath := fs.String("config", "", "JSON gate configuration with thresholds and allowlist")
+	threshold := fs.Float64("threshold", -1, "allowed regression in percent (overrides the config)")
+	metrics := fs.String("metrics", "", "comma separated list of checked metrics (overrides the config)")
+	benchmarks := fs.String("benchmarks", "", "comma separated list of checked benchmarks or scenarios (overrides the config)")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "usage: benchtool gate -baseline old.(out|json) [flags] new.(out|json)")
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 1 || *baselinePath == "" {
+		fs.Usage()
+		return flag.ErrHelp
+	}
+
+	cfg := compare.DefaultGateConfig()
+	if *configPath != "" {
+		var err error
+		if cfg, err = compare.LoadGateConfig(*configPath); err != nil {
+			return err
+		}
+	}
+	if *threshold >= 0 {
+		cfg.Threshold = *threshold
+	}
+	if *metrics != "" {
+		cfg.Metrics = strings.Split(*metrics, ",")
+	}
+	if *benchmarks != "" {
+		cfg.Benchmarks = strings.Split(*benchmarks, ",")
+	}
+
+	baseline, err := result.Load(*baselinePath)
+	if err != nil {
+		return err
+	}
+	run, err := result.Load(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	if len(compare.Compare(baseline, run, cfg.Metrics)) == 0 {
+		return fmt.Errorf("no common sub-benchmarks found")
+	}
+
+	violations, untested := cfg.Check(baseline, run)
+	for i := range violations {
+		fmt.Fprintln(os.Stdout, violations[i].String())
+	}
+	for i := range untested {
+		fmt.Fprintln(os.Stdout, untested[i].String(), "untested")
+	}
+	if len(untested) > 0 {
+		fmt.Fprintf(os.Stdout, "%d regressions above the threshold were not tested for significance, which requires at least %d samples per run (COUNT)\n",
+			len(untested), compare.MinSamples)
+	}
+	if n := len(violations) + len(untested); n > 0 {
+		return fmt.Errorf("%d regressions above the threshold found", n)
+	}
+	fmt.Fprintln(os.Stdout, "no regressions found")
+	return nil
+}
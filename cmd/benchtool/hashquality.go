@@ -0,0 +1,195 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"math/rand"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/google/uuid"
+
+	"bench-hashmaps/dataset"
+	"bench-hashmaps/hashfn"
+)
+
+// qualityKeys is a key set of the hash quality analysis, either integers or strings.
+type qualityKeys struct {
+	keyType, generator string
+	ints               []uint64
+	strings            []string
+}
+
+func runHashQuality(args []string) error {
+	fs := flag.NewFlagSet("hash-quality", flag.ContinueOnError)
+	n := fs.Int("n", 100000, "number of keys per generator")
+	hashes := fs.String("hash", strings.Join(hashfn.Names(), ","), "comma separated hash functions")
+	data := fs.String("dataset", "", "analyze the keys of a dataset file instead of the generators")
+	format := fs.String("dataset-format", "", "format of the dataset: lines, u32 or u64 (default: by extension)")
+	seed := fs.Int64("seed", time.Now().UnixNano(), "seed of the random number generator")
+	asJSON := fs.Bool("json", false, "print the results as JSON")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "usage: benchtool hash-quality [flags]")
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 0 || *n <= 0 {
+		fs.Usage()
+		return flag.ErrHelp
+	}
+	var hashers []hashfn.Hasher
+	for _, name := range strings.Split(*hashes, ",") {
+		h, ok := hashfn.Get(strings.TrimSpace(name))
+		if !ok {
+			return fmt.Errorf("unknown hash %q, available: %s", name, strings.Join(hashfn.Names(), " "))
+		}
+		hashers = append(hashers, h)
+	}
+
+	var sets []qualityKeys
+	if *data != "" {
+		set, err := loadQualityDataset(*data, *format, *n, rand.New(rand.NewSource(*seed)))
+		if err != nil {
+			return err
+		}
+		sets = append(sets, set)
+	} else {
+		sets = genQualityKeys(*n, rand.New(rand.NewSource(*seed)))
+	}
+
+	var results []hashfn.Quality
+	for _, set := range sets {
+		for _, h := range hashers {
+			q := analyzeKeys(h, set)
+			q.Hash, q.KeyType, q.Generator = h.Name, set.keyType, set.generator
+			results = append(results, q)
+		}
+	}
+	if *asJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(results)
+	}
+	return printHashQuality(os.Stdout, results)
+}
+
+// genQualityKeys generates the keys of the benchmark generators: random and shuffled sequential
+// integers like genRandIntArray and genShuffledIntArray, and random UUIDs.
+func genQualityKeys(n int, rng *rand.Rand) []qualityKeys {
+	var sets []qualityKeys
+	for _, keyType := range []string{"U32", "U64"} {
+		random := make([]uint64, 0, n)
+		seen := make(map[uint64]bool, n)
+		for len(random) < n {
+			x := rng.Uint64()
+			if keyType == "U32" {
+				x = uint64(uint32(x))
+			}
+			if !seen[x] {
+				seen[x] = true
+				random = append(random, x)
+			}
+		}
+		sequential := make([]uint64, n)
+		for i := range sequential {
+			sequential[i] = uint64(i + 1)
+		}
+		rng.Shuffle(n, func(i, j int) { sequential[i], sequential[j] = sequential[j], sequential[i] })
+		sets = append(sets,
+			qualityKeys{keyType: keyType, generator: "random", ints: random},
+			qualityKeys{keyType: keyType, generator: "shuffled", ints: sequential})
+	}
+	uuids := make([]string, n)
+	for i := range uuids {
+		id, err := uuid.NewRandomFromReader(rng)
+		if err != nil {
+			panic(err)
+		}
+		uuids[i] = id.String()
+	}
+	return append(sets, qualityKeys{keyType: "UUID", generator: "random", strings: uuids})
+}
+
+// loadQualityDataset loads up to n keys of a dataset.
+func loadQualityDataset(path, format string, n int, rng *rand.Rand) (qualityKeys, error) {
+	f, err := dataset.ParseFormat(format, path)
+	if err != nil {
+		return qualityKeys{}, err
+	}
+	set, err := dataset.Load(path, f)
+	if err != nil {
+		return qualityKeys{}, err
+	}
+	set.Shuffle(rng)
+	keys := qualityKeys{generator: "dataset"}
+	switch set.Format {
+	case dataset.U32:
+		keys.keyType, keys.ints = "U32", set.Ints
+	case dataset.U64:
+		keys.keyType, keys.ints = "U64", set.Ints
+	default:
+		keys.keyType, keys.strings = "string", set.Strings
+	}
+	if len(keys.ints) > n {
+		keys.ints = keys.ints[:n]
+	}
+	if len(keys.strings) > n {
+		keys.strings = keys.strings[:n]
+	}
+	return keys, nil
+}
+
+func analyzeKeys(h hashfn.Hasher, set qualityKeys) hashfn.Quality {
+	switch set.keyType {
+	case "U32":
+		keys := make([]uint32, len(set.ints))
+		for i, x := range set.ints {
+			keys[i] = uint32(x)
+		}
+		return hashfn.Analyze(h.Uint32, keys, hashfn.FlipUint32, 32)
+	case "U64":
+		return hashfn.Analyze(h.Uint64, set.ints, hashfn.FlipUint64, 64)
+	}
+	// flip the bits of the shortest common prefix, at most 16 bytes
+	minLen := 16
+	for _, s := range set.strings {
+		if len(s) < minLen {
+			minLen = len(s)
+		}
+	}
+	return hashfn.Analyze(h.String, set.strings, hashfn.FlipString, 8*minLen)
+}
+
+func printHashQuality(w io.Writer, results []hashfn.Quality) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "keys\tgenerator\thash\tavalanche max/mean\tchi2 z\tcollisions\thit probes\tmiss probes\tverdict\t")
+	var degraded []hashfn.Quality
+	for _, q := range results {
+		verdict := "ok"
+		if q.Degrades {
+			verdict = "DEGRADES"
+			degraded = append(degraded, q)
+		} else if q.WeakAvalanche {
+			verdict = "weak avalanche"
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%.3f/%.3f\t%.1f\t%.2fx\t%.2f (%.1fx)\t%.2f (%.1fx)\t%s\t\n",
+			q.KeyType, q.Generator, q.Hash, q.AvalancheMaxBias, q.AvalancheMeanBias, q.WorstChiSquareZ,
+			q.CollisionRatio, q.HitProbes, q.HitProbeRatio, q.MissProbes, q.MissProbeRatio, verdict)
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+	if len(degraded) > 0 {
+		fmt.Fprintf(w, "\ncombinations degrading open addressing (linear probing at load factor %.1f):\n", hashfn.LoadFactor)
+		for _, q := range degraded {
+			fmt.Fprintf(w, "  %s %s with %s: %s\n", q.KeyType, q.Generator, q.Hash, strings.Join(q.Reasons, "; "))
+		}
+	}
+	return nil
+}
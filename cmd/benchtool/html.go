@@ -0,0 +1,66 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"bench-hashmaps/report"
+	"bench-hashmaps/result"
+)
+
+func runHTML(args []string) error {
+	fs := flag.NewFlagSet("html", flag.ContinueOnError)
+	out := fs.String("o", "", "output html file (default: stdout)")
+	title := fs.String("title", "Golang Hashmap Benchmark", "title of the report")
+	baseline := fs.String("baseline", "std", "baseline map of the speedup tables, disabled if empty")
+	pareto := fs.Bool("pareto", true, "add the Pareto frontiers of time versus memory")
+	cacheSizes := fs.String("cache-sizes", "", "cache sizes of the annotations by level, e.g. 48K,2M,105M (default: caches of the JSON metadata)")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "usage: benchtool html [flags] file.(out|json)")
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 1 {
+		fs.Usage()
+		return flag.ErrHelp
+	}
+
+	run, err := result.Load(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	if len(run.Records) == 0 {
+		return fmt.Errorf("%s: no benchmark results found", fs.Arg(0))
+	}
+
+	data := report.New(*title, run)
+	if data.Caches, err = runCaches(run, *cacheSizes); err != nil {
+		return err
+	}
+	data.AddCacheDrops(run)
+	if *baseline != "" {
+		if err := data.AddSpeedup(run, *baseline); err != nil {
+			fmt.Fprintln(os.Stderr, "speedup tables skipped:", err)
+		}
+	}
+	if *pareto {
+		data.AddPareto(run)
+	}
+
+	if *out == "" {
+		return data.Render(os.Stdout)
+	}
+	f, err := os.Create(*out)
+	if err != nil {
+		return err
+	}
+	if err := data.Render(f); err != nil {
+		f.Close()
+		return err
+	}
+	// a failed close may truncate the report
+	return f.Close()
+}
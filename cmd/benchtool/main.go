@@ -0,0 +1,65 @@
+// Command benchtool analyzes the benchmark results stored in results/.
+//
+// Usage:
+//
+//	benchtool <command> [flags] [files]
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"sort"
+)
+
+type command struct {
+	usage string
+	run   func(args []string) error
+}
+
+var commands = map[string]command{
+	"compare":      {"compare two result files", runCompare},
+	"gate":         {"fail on regressions compared with a baseline", runGate},
+	"html":         {"render a self-contained HTML report", runHTML},
+	"pareto":       {"print the Pareto frontier of time versus memory", runPareto},
+	"recommend":    {"rank the maps for a workload profile", runRecommend},
+	"stats":        {"summarize repetitions and flag noisy cells", runStats},
+	"speedup":      {"print speedup and memory ratios relative to a baseline map", runSpeedup},
+	"summary":      {"print compact tables and sparklines of a run", runSummary},
+	"trace-stats":  {"print the statistics of an operation trace", runTraceStats},
+	"trace-synth":  {"generate a synthetic trace with matching statistics", runTraceSynth},
+	"hash-quality": {"analyze the distribution of the hash functions for the key generators", runHashQuality},
+	"cache-drops":  {"print the slowdowns of the cache sweeps at the cache levels", runCacheDrops},
+}
+
+func usage() {
+	fmt.Fprintln(os.Stderr, "usage: benchtool <command> [flags] [files]")
+	fmt.Fprintln(os.Stderr, "\ncommands:")
+	names := make([]string, 0, len(commands))
+	for name := range commands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		fmt.Fprintf(os.Stderr, "  %-12s %s\n", name, commands[name].usage)
+	}
+}
+
+func main() {
+	if len(os.Args) < 2 {
+		usage()
+		os.Exit(2)
+	}
+	cmd, ok := commands[os.Args[1]]
+	if !ok {
+		fmt.Fprintln(os.Stderr, "unknown command:", os.Args[1])
+		usage()
+		os.Exit(2)
+	}
+	if err := cmd.run(os.Args[2:]); err != nil {
+		if err != flag.ErrHelp {
+			fmt.Fprintln(os.Stderr, "benchtool:", err)
+		}
+		os.Exit(1)
+	}
+}
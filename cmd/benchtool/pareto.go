@@ -0,0 +1,63 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"bench-hashmaps/analysis"
+	"bench-hashmaps/result"
+)
+
+func runPareto(args []string) error {
+	fs := flag.NewFlagSet("pareto", flag.ContinueOnError)
+	size := fs.Int("size", 0, "print only the frontiers of this size (default: all sizes)")
+	benchmark := fs.String("benchmark", "", "print only benchmarks containing this string")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "usage: benchtool pareto [flags] file.(out|json)")
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 1 {
+		fs.Usage()
+		return flag.ErrHelp
+	}
+
+	run, err := result.Load(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	var frontiers []analysis.Frontier
+	for _, f := range analysis.Pareto(run) {
+		if (*size == 0 || f.Size == *size) && strings.Contains(f.Benchmark, *benchmark) {
+			frontiers = append(frontiers, f)
+		}
+	}
+	if len(frontiers) == 0 {
+		return fmt.Errorf("no matching benchmarks with ns/key and B/key metrics found")
+	}
+	return printFrontiers(os.Stdout, frontiers)
+}
+
+func printFrontiers(w io.Writer, frontiers []analysis.Frontier) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	for i, f := range frontiers {
+		if i > 0 {
+			fmt.Fprintln(tw, "\t")
+		}
+		fmt.Fprintf(tw, "%s n=%d\tns/key\tbytes/key\tpareto\tdominated by\t\n", strings.TrimPrefix(f.Benchmark, "Benchmark"), f.Size)
+		for _, p := range f.Points {
+			mark := ""
+			if p.Optimal {
+				mark = "*"
+			}
+			fmt.Fprintf(tw, "  %s\t%.2f\t%.1f\t%s\t%s\t\n", p.Map, p.Time, p.BytesPerEntry, mark, strings.Join(p.DominatedBy, " "))
+		}
+	}
+	return tw.Flush()
+}
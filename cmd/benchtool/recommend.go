@@ -0,0 +1,122 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"bench-hashmaps/analysis"
+	"bench-hashmaps/result"
+)
+
+// parseWeights parses a list like "read=0.9,insert=0.1".
+func parseWeights(s string) (map[string]float64, error) {
+	weights := make(map[string]float64)
+	for _, item := range strings.Split(s, ",") {
+		name, value, found := strings.Cut(item, "=")
+		if !found {
+			return nil, fmt.Errorf("invalid weight %q, expected name=value", item)
+		}
+		w, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid weight %q: %w", item, err)
+		}
+		weights[strings.TrimSpace(name)] = w
+	}
+	return weights, nil
+}
+
+func runRecommend(args []string) error {
+	fs := flag.NewFlagSet("recommend", flag.ContinueOnError)
+	profilePath := fs.String("profile", "", "JSON workload profile")
+	keyType := fs.String("keytype", "", "key type of the workload, e.g. U64 or UUID (overrides the profile)")
+	size := fs.Int("size", 0, "expected number of entries (overrides the profile)")
+	weights := fs.String("weights", "", "scenario weights, e.g. FullReads=0.8,RandomFullInserts=0.2 (overrides the profile)")
+	mix := fs.String("mix", "", "operation ratios of read, miss, insert, delete and iterate, e.g. read=0.9,insert=0.1 (overrides the profile)")
+	memoryWeight := fs.Float64("memory-weight", -1, "weight of the memory per entry (overrides the profile)")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "usage: benchtool recommend [flags] file.(out|json)")
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 1 {
+		fs.Usage()
+		return flag.ErrHelp
+	}
+
+	var p analysis.Profile
+	if *profilePath != "" {
+		var err error
+		if p, err = analysis.LoadProfile(*profilePath); err != nil {
+			return err
+		}
+	}
+	if *keyType != "" {
+		p.KeyType = *keyType
+	}
+	if *size != 0 {
+		p.Size = *size
+	}
+	if *memoryWeight >= 0 {
+		p.MemoryWeight = *memoryWeight
+	}
+	if *weights != "" {
+		w, err := parseWeights(*weights)
+		if err != nil {
+			return err
+		}
+		p.Weights, p.Mix = w, nil
+	}
+	if *mix != "" {
+		m, err := parseWeights(*mix)
+		if err != nil {
+			return err
+		}
+		p.Mix, p.Weights = m, nil
+	}
+
+	run, err := result.Load(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	r, err := analysis.Rank(run, p)
+	if err != nil {
+		return err
+	}
+	return printRanking(os.Stdout, r)
+}
+
+func printRanking(w io.Writer, r *analysis.Ranking) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(tw, "rank\tmap\tscore\t")
+	for _, s := range r.Entries[0].Scenarios {
+		fmt.Fprintf(tw, "%s n=%d (%.2f)\t", strings.TrimPrefix(s.Benchmark, "Benchmark"), s.Size, s.Weight)
+	}
+	fmt.Fprintln(tw)
+	for i, e := range r.Entries {
+		fmt.Fprintf(tw, "%d\t%s\t%.3f\t", i+1, e.Map, e.Score)
+		for _, s := range e.Scenarios {
+			fmt.Fprintf(tw, "%.2fx\t", s.Relative)
+		}
+		fmt.Fprintln(tw)
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+
+	fmt.Fprintf(w, "\nrecommendation: %s (confidence %s, %.2f)\n", r.Entries[0].Map, r.ConfidenceLevel(), r.Confidence)
+	fmt.Fprintf(w, "  %s\n", r.Justification())
+	for _, reason := range r.Reasons {
+		fmt.Fprintf(w, "  - %s\n", reason)
+	}
+	if len(r.Excluded) > 0 {
+		fmt.Fprintf(w, "  - excluded without results for all scenarios: %s\n", strings.Join(r.Excluded, ", "))
+	}
+	return nil
+}
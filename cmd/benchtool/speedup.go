@@ -0,0 +1,88 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"bench-hashmaps/analysis"
+	"bench-hashmaps/result"
+)
+
+func runSpeedup(args []string) error {
+	fs := flag.NewFlagSet("speedup", flag.ContinueOnError)
+	baseline := fs.String("baseline", analysis.DefaultBaseline, "map all other maps are compared with")
+	size := fs.Int("size", 0, "print only the tables of this size (default: all sizes)")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "usage: benchtool speedup [flags] file.(out|json)")
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 1 {
+		fs.Usage()
+		return flag.ErrHelp
+	}
+
+	run, err := result.Load(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	t, err := analysis.Speedup(run, *baseline)
+	if err != nil {
+		return err
+	}
+	return printSpeedup(os.Stdout, t, *size)
+}
+
+func printSpeedup(w io.Writer, t *analysis.SpeedupTable, size int) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', tabwriter.AlignRight)
+	printed := false
+	for _, s := range t.Sizes {
+		if size != 0 && s != size {
+			continue
+		}
+		printed = true
+		for _, kind := range []string{"speedup", "memory"} {
+			fmt.Fprintf(tw, "%s relative to %s, n=%d\t%s\t\n", kind, t.Baseline, s, strings.Join(t.Maps, "\t"))
+			for _, b := range t.Benchmarks {
+				fmt.Fprintf(tw, "%s\t", strings.TrimPrefix(b, "Benchmark"))
+				for _, m := range t.Maps {
+					fmt.Fprintf(tw, "%s\t", formatRatio(t.Cell(b, m, s), kind))
+				}
+				fmt.Fprintln(tw)
+			}
+			fmt.Fprintf(tw, "geomean\t")
+			for _, m := range t.Maps {
+				fmt.Fprintf(tw, "%s\t", formatRatio(t.SizeGeoMean[s][m], kind))
+			}
+			fmt.Fprint(tw, "\n\t\n")
+		}
+	}
+	if !printed {
+		return fmt.Errorf("size %d not found", size)
+	}
+
+	fmt.Fprintf(tw, "geomean over all sizes\tspeedup\tmemory\t\n")
+	for _, m := range t.Maps {
+		r := t.GeoMean[m]
+		fmt.Fprintf(tw, "%s\t%s\t%s\t\n", m, formatRatio(r, "speedup"), formatRatio(r, "memory"))
+	}
+	return tw.Flush()
+}
+
+func formatRatio(r analysis.Ratio, kind string) string {
+	v := r.Speedup
+	if kind == "memory" {
+		v = r.Memory
+	}
+	if math.IsNaN(v) {
+		return "-"
+	}
+	return fmt.Sprintf("%.2fx", v)
+}
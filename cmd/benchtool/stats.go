@@ -0,0 +1,65 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"bench-hashmaps/analysis"
+	"bench-hashmaps/result"
+)
+
+func runStats(args []string) error {
+	fs := flag.NewFlagSet("stats", flag.ContinueOnError)
+	metric := fs.String("metric", "ns/key", "summarized metric")
+	maxCV := fs.Float64("max-cv", analysis.DefaultMaxCV, "coefficient of variation above which a cell is flagged as noisy")
+	onlyNoisy := fs.Bool("noisy", false, "print only noisy cells")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "usage: benchtool stats [flags] file.(out|json)")
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 1 {
+		fs.Usage()
+		return flag.ErrHelp
+	}
+
+	run, err := result.Load(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	cells := analysis.Summarize(run, *metric)
+	if len(cells) == 0 {
+		return fmt.Errorf("metric %q not found", *metric)
+	}
+	return printCells(os.Stdout, cells, *maxCV, *onlyNoisy)
+}
+
+func printCells(w io.Writer, cells []analysis.Cell, maxCV float64, onlyNoisy bool) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(tw, "benchmark\tmap\tsize\tn\tmean\tstddev\t95%% CI\tCV\t\t\n")
+	noisy := 0
+	for i := range cells {
+		c := &cells[i]
+		mark := ""
+		if c.Noisy(maxCV) {
+			mark = "noisy"
+			noisy++
+		} else if onlyNoisy {
+			continue
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%d\t%d\t%s\t%s\t± %s\t%.1f%%\t%s\t\n",
+			strings.TrimPrefix(c.Benchmark, "Benchmark"), c.Map, c.Size, c.N,
+			formatValue(c.Mean), formatValue(c.StdDev), formatValue(c.CI), c.CV()*100, mark)
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+	_, err := fmt.Fprintf(w, "\n%d of %d cells are noisy (CV > %.1f%%)\n", noisy, len(cells), maxCV*100)
+	return err
+}
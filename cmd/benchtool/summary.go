@@ -0,0 +1,203 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"bench-hashmaps/analysis"
+	"bench-hashmaps/result"
+	"bench-hashmaps/stats"
+)
+
+var sparkBlocks = []rune("▁▂▃▄▅▆▇█")
+
+// sparkline renders the values as unicode block characters scaled between min and max.
+func sparkline(values []float64, min, max float64) string {
+	var sb strings.Builder
+	for _, v := range values {
+		if math.IsNaN(v) {
+			sb.WriteRune(' ')
+			continue
+		}
+		i := 0
+		if max > min {
+			i = int((v - min) / (max - min) * float64(len(sparkBlocks)-1))
+		}
+		sb.WriteRune(sparkBlocks[i])
+	}
+	return sb.String()
+}
+
+func runSummary(args []string) error {
+	fs := flag.NewFlagSet("summary", flag.ContinueOnError)
+	metric := fs.String("metric", "ns/key", "summarized metric")
+	noColor := fs.Bool("no-color", false, "disable the highlighting of the winner")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "usage: benchtool summary [flags] [file.(out|json)]")
+		fmt.Fprintln(fs.Output(), "reads the go test output from stdin without file")
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	var (
+		run *result.Run
+		err error
+	)
+	switch fs.NArg() {
+	case 0:
+		if run, err = result.Read(os.Stdin); err == nil {
+			run.Derive()
+		}
+	case 1:
+		run, err = result.Load(fs.Arg(0))
+	default:
+		fs.Usage()
+		return flag.ErrHelp
+	}
+	if err != nil {
+		return err
+	}
+	cells := analysis.Summarize(run, *metric)
+	if len(cells) == 0 {
+		return fmt.Errorf("metric %q not found", *metric)
+	}
+	return printSummary(os.Stdout, cells, *metric, !*noColor && isTerminal(os.Stdout))
+}
+
+// isTerminal reports whether the file is a terminal, the escape sequences would garble redirected output.
+func isTerminal(f *os.File) bool {
+	info, err := f.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+// printSummary prints per benchmark a table with the metric of each map over all sizes,
+// a sparkline of its trend and the number of sizes, the map won. The maps are sorted by the
+// geometric mean over the sizes, so the large sizes with the larger values do not dominate.
+func printSummary(w io.Writer, cells []analysis.Cell, metric string, color bool) error {
+	type series struct {
+		values map[int]float64
+		wins   int
+	}
+	benchmarks := make(map[string]map[string]*series)
+	var benchOrder []string
+	mapOrder := make(map[string][]string)
+	sizes := make(map[string][]int)
+	for _, c := range cells {
+		if c.Size <= 0 {
+			continue
+		}
+		maps, ok := benchmarks[c.Benchmark]
+		if !ok {
+			maps = make(map[string]*series)
+			benchmarks[c.Benchmark] = maps
+			benchOrder = append(benchOrder, c.Benchmark)
+		}
+		s, ok := maps[c.Map]
+		if !ok {
+			s = &series{values: make(map[int]float64)}
+			maps[c.Map] = s
+			mapOrder[c.Benchmark] = append(mapOrder[c.Benchmark], c.Map)
+		}
+		if _, ok := s.values[c.Size]; !ok {
+			found := false
+			for _, size := range sizes[c.Benchmark] {
+				found = found || size == c.Size
+			}
+			if !found {
+				sizes[c.Benchmark] = append(sizes[c.Benchmark], c.Size)
+			}
+		}
+		s.values[c.Size] = c.Mean
+	}
+	sort.Strings(benchOrder)
+
+	// better reports whether a is better than b
+	better := func(a, b float64) bool { return a < b }
+	if result.HigherIsBetter(metric) {
+		better = func(a, b float64) bool { return a > b }
+	}
+	bold, reset := "", ""
+	if color {
+		bold, reset = "\033[1;32m", "\033[0m"
+	}
+	for _, b := range benchOrder {
+		maps := benchmarks[b]
+		sort.Ints(sizes[b])
+		minV, maxV := math.Inf(1), math.Inf(-1)
+		for _, size := range sizes[b] {
+			best := ""
+			for _, m := range mapOrder[b] {
+				v, ok := maps[m].values[size]
+				if !ok {
+					continue
+				}
+				minV, maxV = math.Min(minV, v), math.Max(maxV, v)
+				if best == "" || better(v, maps[best].values[size]) {
+					best = m
+				}
+			}
+			if best != "" {
+				maps[best].wins++
+			}
+		}
+
+		names := mapOrder[b]
+		sort.SliceStable(names, func(i, j int) bool {
+			return better(geoMeanOf(maps[names[i]].values), geoMeanOf(maps[names[j]].values))
+		})
+		sizeList := sizes[b]
+		fmt.Fprintf(w, "%s (%s, n=%d..%d)\n", strings.TrimPrefix(b, "Benchmark"), metric, sizeList[0], sizeList[len(sizeList)-1])
+		var table bytes.Buffer
+		tw := tabwriter.NewWriter(&table, 0, 0, 2, ' ', 0)
+		fmt.Fprintf(tw, "  map\tmin\tgeomean\tmax\ttrend\twins\t\n")
+		for _, m := range names {
+			s := maps[m]
+			values := make([]float64, len(sizeList))
+			lo, hi := math.Inf(1), math.Inf(-1)
+			for j, size := range sizeList {
+				v, ok := s.values[size]
+				if !ok {
+					values[j] = math.NaN()
+					continue
+				}
+				values[j] = v
+				lo, hi = math.Min(lo, v), math.Max(hi, v)
+			}
+			fmt.Fprintf(tw, "  %s\t%.2f\t%.2f\t%.2f\t%s\t%d/%d\t\n", m, lo, geoMeanOf(s.values), hi,
+				sparkline(values, minV, maxV), s.wins, len(sizeList))
+		}
+		if err := tw.Flush(); err != nil {
+			return err
+		}
+		// the winner is the first map, the line is highlighted after the alignment
+		// because the tab writer counts the escape sequences as text
+		lines := strings.SplitAfter(table.String(), "\n")
+		for i, line := range lines {
+			if i == 1 {
+				line = bold + strings.TrimSuffix(line, "\n") + reset + " <- winner\n"
+			}
+			if _, err := io.WriteString(w, line); err != nil {
+				return err
+			}
+		}
+		fmt.Fprintln(w)
+	}
+	return nil
+}
+
+func geoMeanOf(values map[int]float64) float64 {
+	list := make([]float64, 0, len(values))
+	for _, v := range values {
+		list = append(list, v)
+	}
+	return stats.GeoMean(list)
+}
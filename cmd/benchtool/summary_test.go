@@ -0,0 +1,117 @@
+package main
+
+import (
+	"bytes"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"bench-hashmaps/analysis"
+	"bench-hashmaps/result"
+)
+
+func TestSparkline(t *testing.T) {
+	tests := []struct {
+		values   []float64
+		min, max float64
+		want     string
+	}{
+		{nil, 0, 1, ""},
+		{[]float64{0, 0.5, 1}, 0, 1, "▁▄█"},
+		{[]float64{2, 4, 8}, 2, 8, "▁▃█"},
+		{[]float64{1, math.NaN(), 3}, 1, 3, "▁ █"},
+		{[]float64{5, 5}, 5, 5, "▁▁"},
+	}
+	for _, tt := range tests {
+		if got := sparkline(tt.values, tt.min, tt.max); got != tt.want {
+			t.Errorf("sparkline(%v, %v, %v) = %q, want %q", tt.values, tt.min, tt.max, got, tt.want)
+		}
+	}
+}
+
+func TestGeoMeanOf(t *testing.T) {
+	tests := []struct {
+		values map[int]float64
+		want   float64
+	}{
+		{map[int]float64{1: 4}, 4},
+		{map[int]float64{1: 1, 2: 100}, 10},
+		{map[int]float64{1: 2, 2: 4, 3: 8}, 4},
+	}
+	for _, tt := range tests {
+		if got := geoMeanOf(tt.values); math.Abs(got-tt.want) > 1e-9 {
+			t.Errorf("geoMeanOf(%v) = %v, want %v", tt.values, got, tt.want)
+		}
+	}
+}
+
+func TestIsTerminal(t *testing.T) {
+	file, err := os.Create(filepath.Join(t.TempDir(), "summary.txt"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	pipe, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer pipe.Close()
+	defer w.Close()
+	tests := []struct {
+		name string
+		file *os.File
+		want bool
+	}{
+		{"regular file", file, false},
+		{"pipe", pipe, false},
+		{"invalid file", os.NewFile(^uintptr(0), "invalid"), false},
+	}
+	if tty, err := os.Open("/dev/tty"); err == nil {
+		defer tty.Close()
+		tests = append(tests, struct {
+			name string
+			file *os.File
+			want bool
+		}{"terminal", tty, true})
+	}
+	for _, tt := range tests {
+		if got := isTerminal(tt.file); got != tt.want {
+			t.Errorf("%s: isTerminal = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+const summaryInput = `BenchmarkU64FullReads/std-1000         	1	100000 ns/op
+BenchmarkU64FullReads/swiss-1000       	1	50000 ns/op
+BenchmarkU64FullReads/std-10000        	1	2000000 ns/op
+BenchmarkU64FullReads/swiss-10000      	1	3000000 ns/op
+BenchmarkU64FullReads/std-100000       	1	40000000 ns/op
+BenchmarkU64FullReads/swiss-100000     	1	20000000 ns/op
+`
+
+// summaryGolden is the expected summary of summaryInput, the tab writer pads the last column.
+var summaryGolden = strings.Join([]string{
+	"U64FullReads (ns/key, n=1000..100000)",
+	"  map    min     geomean  max     trend  wins  ",
+	"  swiss  50.00   144.22   300.00  ▁▆▄    2/3    <- winner",
+	"  std    100.00  200.00   400.00  ▂▄█    1/3   ",
+	"",
+	"",
+}, "\n")
+
+func TestPrintSummary(t *testing.T) {
+	run, err := result.Read(strings.NewReader(summaryInput))
+	if err != nil {
+		t.Fatal(err)
+	}
+	run.Derive()
+	var buf bytes.Buffer
+	if err := printSummary(&buf, analysis.Summarize(run, "ns/key"), "ns/key", false); err != nil {
+		t.Fatal(err)
+	}
+	if got := buf.String(); got != summaryGolden {
+		t.Errorf("got summary\n%s\nwant\n%s", got, summaryGolden)
+	}
+}
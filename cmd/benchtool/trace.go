@@ -0,0 +1,176 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"math/rand"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"bench-hashmaps/trace"
+)
+
+func runTraceStats(args []string) error {
+	fs := flag.NewFlagSet("trace-stats", flag.ContinueOnError)
+	windows := fs.Int("windows", 10, "number of time windows")
+	asJSON := fs.Bool("json", false, "print the statistics as JSON")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "usage: benchtool trace-stats [flags] file.trace")
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 1 {
+		fs.Usage()
+		return flag.ErrHelp
+	}
+
+	s, err := trace.ReadStats(fs.Arg(0), *windows)
+	if err != nil {
+		return err
+	}
+	if *asJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(s)
+	}
+	return printTraceStats(os.Stdout, s)
+}
+
+func runTraceSynth(args []string) error {
+	fs := flag.NewFlagSet("trace-synth", flag.ContinueOnError)
+	out := fs.String("o", "", "path of the synthetic trace (required)")
+	n := fs.Int("n", 0, "number of events (default: events of the input)")
+	windows := fs.Int("windows", 10, "number of time windows, whose operation mix is reproduced")
+	seed := fs.Int64("seed", time.Now().UnixNano(), "seed of the random number generator")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "usage: benchtool trace-synth [flags] -o out.trace (file.trace|stats.json)")
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 1 || *out == "" {
+		fs.Usage()
+		return flag.ErrHelp
+	}
+
+	var s *trace.Stats
+	var err error
+	if strings.HasSuffix(fs.Arg(0), ".json") {
+		s, err = readTraceStats(fs.Arg(0))
+	} else {
+		s, err = trace.ReadStats(fs.Arg(0), *windows)
+	}
+	if err != nil {
+		return err
+	}
+	if *n <= 0 {
+		*n = s.Events
+	}
+	f, err := os.Create(*out)
+	if err != nil {
+		return err
+	}
+	// Synthesize closes the file
+	return trace.Synthesize(s, f, *n, rand.New(rand.NewSource(*seed)))
+}
+
+// readTraceStats reads the statistics written by trace-stats -json, which can be shared without the trace.
+func readTraceStats(path string) (*trace.Stats, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	s := &trace.Stats{}
+	if err := json.Unmarshal(data, s); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	if err := s.Validate(); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return s, nil
+}
+
+func sortedOps(ops map[string]int) []string {
+	names := make([]string, 0, len(ops))
+	for name := range ops {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func percent(count, total int) float64 {
+	if total == 0 {
+		return 0
+	}
+	return float64(count) / float64(total) * 100
+}
+
+func printTraceStats(w io.Writer, s *trace.Stats) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	key := s.KeyKind
+	if s.KeySize > 0 {
+		key += fmt.Sprintf(" (%d bytes)", s.KeySize)
+	}
+	fmt.Fprintf(tw, "keys\t%s, %s, sample rate %g\t\n", key, s.Encoding, s.SampleRate)
+	fmt.Fprintf(tw, "events\t%d in %s\t\n", s.Events, s.Duration)
+	fmt.Fprintf(tw, "distinct keys\t%d\t\n", s.DistinctKeys)
+	fmt.Fprintf(tw, "hit rate\t%.1f%%\t\n", s.HitRate*100)
+	fmt.Fprintf(tw, "inserts\t%d (%.1f/s)\t\n", s.Inserts, s.InsertRate)
+	fmt.Fprintf(tw, "updates\t%d\t\n", s.Updates)
+	fmt.Fprintf(tw, "deletes\t%d (%.1f/s)\t\n", s.Deletes, s.DeleteRate)
+	fmt.Fprintf(tw, "zipf fit\ts=%.3f R²=%.3f over %d ranks\t\n", s.Zipf.S, s.Zipf.R2, s.Zipf.Ranks)
+
+	fmt.Fprintln(tw, "\t")
+	fmt.Fprintln(tw, "operation\tcount\tmix\tfirst access\t")
+	for _, op := range sortedOps(s.Ops) {
+		fmt.Fprintf(tw, "%s\t%d\t%.1f%%\t%.1f%%\t\n", op, s.Ops[op], percent(s.Ops[op], s.Events), percent(s.Cold[op], s.Ops[op]))
+	}
+
+	fmt.Fprintln(tw, "\t")
+	fmt.Fprintln(tw, "window\tevents\tworking set\tlive keys\tmix\t")
+	for i, win := range s.Windows {
+		var mix []string
+		for _, op := range sortedOps(win.Ops) {
+			mix = append(mix, fmt.Sprintf("%s %.0f%%", op, percent(win.Ops[op], win.Events)))
+		}
+		fmt.Fprintf(tw, "%d [%s, %s]\t%d\t%d\t%d\t%s\t\n", i+1, win.Start.Round(time.Microsecond), win.End.Round(time.Microsecond),
+			win.Events, win.WorkingSet, win.LiveKeys, strings.Join(mix, ", "))
+	}
+
+	fmt.Fprintln(tw, "\t")
+	printHistogram(tw, "reuse distance", s.Reuse)
+	fmt.Fprintln(tw, "\t")
+	printHistogram(tw, "key length", s.KeyLengths)
+	return tw.Flush()
+}
+
+func printHistogram(w io.Writer, name string, buckets []trace.Bucket) {
+	total, max := 0, 0
+	for _, b := range buckets {
+		total += b.Count
+		if b.Count > max {
+			max = b.Count
+		}
+	}
+	fmt.Fprintf(w, "%s\tcount\tshare\t\t\n", name)
+	for _, b := range buckets {
+		if b.Count == 0 {
+			continue
+		}
+		bin := fmt.Sprint(b.Min)
+		if b.Max > b.Min {
+			bin = fmt.Sprintf("%d-%d", b.Min, b.Max)
+		}
+		bar := strings.Repeat("#", (b.Count*40+max-1)/max)
+		fmt.Fprintf(w, "%s\t%d\t%.1f%%\t%s\t\n", bin, b.Count, percent(b.Count, total), bar)
+	}
+}
@@ -0,0 +1,254 @@
+package bench_test
+
+import (
+	"fmt"
+	"math/rand"
+	"runtime"
+	"testing"
+	"time"
+
+	"bench-hashmaps/cpucache"
+)
+
+// coldBatches is the number of batches of lookups of an iteration, each batch reads cfg.ColdBatch keys.
+const coldBatches = 32
+
+// coldScenarios are the read scenarios of BenchmarkColdReads per key type, see the scenarios of the same
+// name, e.g. BenchmarkU64FullReads.
+var coldScenarios = map[string][]string{
+	"U32":  {"RandomShuffleReads", "FullReads", "FullReadsMisses", "RandomFullReadsAfterDeletingHalf"},
+	"U64":  {"RandomShuffleReads", "FullReads", "FullReadsMisses", "RandomFullReadsAfterDeletingHalf"},
+	"UUID": {"RandomReads", "ReadsMisses", "RandomFullReadsAfterDeletingHalf"},
+}
+
+var (
+	// evictBuffer is streamed through to evict the CPU caches, it is twice the size of the last level cache.
+	// It is only allocated during BenchmarkColdReads, so it does not count to the memory of other benchmarks.
+	evictBuffer []byte
+	// evictStride is the cache line size.
+	evictStride int
+	evictSink   byte
+)
+
+// evictCaches reads a byte of each cache line of the evict buffer, which displaces the entries of the
+// maps and keys from all cache levels and the TLB.
+func evictCaches() {
+	var sum byte
+	for i := 0; i < len(evictBuffer); i += evictStride {
+		sum += evictBuffer[i]
+	}
+	evictSink = sum
+}
+
+// touchKeys reads the looked up keys after the eviction, like keys of a request, which was just parsed,
+// so only the map is cold. The string keys are read including their bytes.
+func touchKeys[K ordered](keys []K) {
+	var sum byte
+	if strs, ok := any(keys).([]string); ok {
+		for _, k := range strs {
+			for i := 0; i < len(k); i++ {
+				sum += k[i]
+			}
+		}
+	} else {
+		for i := range keys {
+			if keys[i] == keys[0] {
+				sum++
+			}
+		}
+	}
+	evictSink += sum
+}
+
+// setupEviction allocates the evict buffer for the last level cache. The buffer is written once, because
+// untouched pages are all mapped to the same zero page.
+func setupEviction() bool {
+	llc, ok := cpucache.LLC(caches)
+	if !ok {
+		return false
+	}
+	evictStride = llc.LineSize
+	if evictStride <= 0 {
+		evictStride = 64
+	}
+	evictBuffer = make([]byte, 2*llc.Size)
+	for i := range evictBuffer {
+		evictBuffer[i] = byte(i)
+	}
+	return true
+}
+
+// BenchmarkColdReads runs the read scenarios with warm and with cold CPU caches. The lookups are done in
+// coldBatches batches of cfg.ColdBatch keys. In the Cold mode, the caches are evicted before each batch
+// by streaming through a buffer of twice the size of the detected last level cache, like lookups in maps
+// which were not touched for milliseconds. In the Warm mode, the caches are warm from the prefill and
+// the previous batches. The sizes are cfg.ColdSizes, the sub-benchmarks are named by key type, scenario
+// and mode, e.g. BenchmarkColdReads/U64FullReadsCold/swiss-100000.
+func BenchmarkColdReads(b *testing.B) {
+	if !setupEviction() {
+		b.Skip("no caches detected, set them with -cache-sizes")
+	}
+	defer func() {
+		evictBuffer = nil
+		runtime.GC()
+	}()
+	for _, keyType := range keyTypeNames {
+		for _, scenario := range coldScenarios[keyType] {
+			if !cfg.selects(keyType, scenario, "ColdReads") {
+				continue
+			}
+			for _, mode := range []string{"Warm", "Cold"} {
+				b.Run(keyType+scenario+mode, func(b *testing.B) {
+					for _, n := range cfg.ColdSizes {
+						switch keyType {
+						case "U32":
+							insert, lookups := genColdInts[uint32](scenario, n)
+							runColdReads(b, mode, insert, lookups, scenario == "RandomFullReadsAfterDeletingHalf")
+						case "U64":
+							insert, lookups := genColdInts[uint64](scenario, n)
+							runColdReads(b, mode, insert, lookups, scenario == "RandomFullReadsAfterDeletingHalf")
+						case "UUID":
+							insert, lookups := genColdStrings(scenario, n)
+							runColdReads(b, mode, insert, lookups, scenario == "RandomFullReadsAfterDeletingHalf")
+						}
+					}
+				})
+			}
+		}
+	}
+}
+
+// genColdInts returns the inserted and looked up keys of an integer read scenario.
+func genColdInts[V uint32 | uint64](scenario string, n int) (insert, lookups []V) {
+	switch scenario {
+	case "RandomShuffleReads":
+		insert = genShuffledIntArray[V](n)
+	case "FullReadsMisses":
+		insert = genRandIntArray[V](n)
+		return insert, genDifferentRandIntArray(insert)
+	default:
+		insert = genRandIntArray[V](n)
+	}
+	return insert, append([]V(nil), insert...)
+}
+
+// genColdStrings returns the inserted and looked up keys of a UUID read scenario.
+func genColdStrings(scenario string, n int) (insert, lookups []string) {
+	insert = genUUIDArray(n)
+	if scenario == "ReadsMisses" {
+		return insert, genDifferentUUIDArray(insert)
+	}
+	return insert, append([]string(nil), insert...)
+}
+
+// runColdReads builds the map of the scenario and looks up the keys in batches, only the lookups are timed.
+// With deleteHalf, a random half of the keys is removed before. Besides the default metrics, where ns/key
+// and Mops/s are per lookup, the Cold mode reports the slowdown relative to warm caches as x-warm. The warm
+// time is measured in the same iteration by looking up all batches again without eviction, so x-warm does
+// not depend on the Warm sub-benchmark.
+func runColdReads[K ordered](b *testing.B, mode string, insert, lookups []K, deleteHalf bool) {
+	n := len(insert)
+	batch := cfg.ColdBatch
+	if batch > len(lookups) {
+		batch = len(lookups)
+	}
+	for _, mapName := range getMapNames() {
+		b.Run(fmt.Sprintf("%s-%d", mapName, n), func(b *testing.B) {
+			load := float32(-1.0)
+			count := 0
+			var warm time.Duration
+			for i := 0; i < b.N; i++ {
+				b.StopTimer()
+				var removed []K
+				if deleteHalf {
+					removed = append([]K(nil), insert...)
+					rand.Shuffle(len(removed), func(i, j int) { removed[i], removed[j] = removed[j], removed[i] })
+					removed = removed[:n/2]
+				}
+				rand.Shuffle(len(lookups), func(i, j int) { lookups[i], lookups[j] = lookups[j], lookups[i] })
+				batches := make([][]K, coldBatches)
+				for j := range batches {
+					batches[j] = lookups[j*batch%(len(lookups)-batch+1):][:batch]
+				}
+				// the expected hits are counted before the prefill, so no other structure
+				// displaces the map from the caches between the batches
+				want := coldHits(insert, removed, batches)
+
+				m := createMap[K, uint64](0, mapName)
+				for _, k := range insert {
+					m.Put(k, 1)
+				}
+				for _, k := range removed {
+					m.Remove(k)
+				}
+
+				found := 0
+				for _, keys := range batches {
+					if mode == "Cold" {
+						evictCaches()
+						touchKeys(keys)
+					}
+					b.StartTimer()
+					for _, k := range keys {
+						if _, ok := m.Get(k); ok {
+							found++
+						}
+					}
+					b.StopTimer()
+				}
+				if mode == "Cold" {
+					start := time.Now()
+					for _, keys := range batches {
+						for _, k := range keys {
+							if _, ok := m.Get(k); ok {
+								found++
+							}
+						}
+					}
+					warm += time.Since(start)
+					want *= 2
+				}
+				if found != want {
+					b.Fatalf("%s: found %d of %d keys", mapName, found, want)
+				}
+				count += coldBatches * batch
+				load = m.Load()
+			}
+			report(b, n, load)
+			// the evict buffer is not part of the measured map
+			var mem runtime.MemStats
+			runtime.ReadMemStats(&mem)
+			alloc := float64(mem.Alloc) - float64(len(evictBuffer))
+			b.ReportMetric(alloc, "Bytes")
+			b.ReportMetric(alloc/float64(n), "B/key")
+			nsPerKey := float64(b.Elapsed().Nanoseconds()) / float64(count)
+			b.ReportMetric(nsPerKey, "ns/key")
+			if nsPerKey > 0 {
+				b.ReportMetric(1e3/nsPerKey, "Mops/s")
+			}
+			if warm > 0 {
+				b.ReportMetric(nsPerKey/(float64(warm.Nanoseconds())/float64(count)), "x-warm")
+			}
+		})
+	}
+}
+
+// coldHits returns the number of looked up keys in all batches, which are inserted and not removed.
+func coldHits[K comparable](insert, removed []K, batches [][]K) int {
+	present := make(map[K]bool, len(insert))
+	for _, k := range insert {
+		present[k] = true
+	}
+	for _, k := range removed {
+		delete(present, k)
+	}
+	hits := 0
+	for _, keys := range batches {
+		for _, k := range keys {
+			if present[k] {
+				hits++
+			}
+		}
+	}
+	return hits
+}
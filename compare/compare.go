@@ -0,0 +1,76 @@
+// Package compare compares the metrics of two benchmark runs.
+package compare
+
+import (
+	"math"
+
+	"bench-hashmaps/result"
+	"bench-hashmaps/stats"
+)
+
+// Alpha is the significance level of the comparison.
+const Alpha = 0.05
+
+// DefaultMetrics are the metrics compared by default.
+var DefaultMetrics = []string{"ns/key", "B/key", "allocs/op"}
+
+// Delta is the comparison of one metric of one map in one sub-benchmark.
+type Delta struct {
+	result.Key
+	Metric string
+	Old    stats.Summary
+	New    stats.Summary
+	// Change is the relative change of the mean in percent.
+	Change float64
+	// Regression is the relative change in percent, where positive values are worse
+	// independent of the direction of the metric.
+	Regression float64
+	// P is the p-value of the Mann-Whitney U-test.
+	P float64
+}
+
+// Significant reports whether the difference is statistically significant.
+// With a single sample per run the significance can not be tested.
+func (d *Delta) Significant() bool {
+	return d.P < Alpha
+}
+
+// Compare compares the given metrics of all sub-benchmarks contained in both runs.
+func Compare(old, new *result.Run, metrics []string) []Delta {
+	var deltas []Delta
+	for _, metric := range metrics {
+		_, oldGroups := old.Group(metric)
+		keys, newGroups := new.Group(metric)
+		for _, k := range keys {
+			oldValues, ok := oldGroups[k]
+			if !ok {
+				continue
+			}
+			newValues := newGroups[k]
+			d := Delta{
+				Key:    k,
+				Metric: metric,
+				Old:    stats.Summarize(oldValues),
+				New:    stats.Summarize(newValues),
+				P:      stats.MannWhitneyU(oldValues, newValues),
+			}
+			d.Change = relChange(d.Old.Mean, d.New.Mean)
+			d.Regression = d.Change
+			if result.HigherIsBetter(metric) {
+				d.Regression = -d.Change
+			}
+			deltas = append(deltas, d)
+		}
+	}
+	return deltas
+}
+
+func relChange(old, new float64) float64 {
+	if old == new {
+		return 0
+	}
+	if old == 0 {
+		return math.Inf(1)
+	}
+	return (new - old) / math.Abs(old) * 100
+}
@@ -0,0 +1,123 @@
+package compare
+
+import (
+	"math"
+	"testing"
+
+	"bench-hashmaps/result"
+)
+
+// newRun returns a run with one record per value of the metric for each sub-benchmark.
+func newRun(metric string, values map[result.Key][]float64) *result.Run {
+	run := &result.Run{}
+	for k, vs := range values {
+		for _, v := range vs {
+			run.Records = append(run.Records, result.Record{
+				Benchmark: k.Benchmark,
+				Map:       k.Map,
+				Size:      k.Size,
+				Metrics:   map[string]float64{metric: v},
+			})
+		}
+	}
+	return run
+}
+
+func TestCompare(t *testing.T) {
+	k := result.Key{Benchmark: "BenchmarkU64FullReads", Map: "std", Size: 1000}
+	other := result.Key{Benchmark: "BenchmarkU64FullReads", Map: "swiss", Size: 1000}
+	tests := []struct {
+		metric         string
+		old, new       []float64
+		wantChange     float64
+		wantRegression float64
+		significant    bool
+	}{
+		{"ns/key", []float64{10}, []float64{12}, 20, 20, false},
+		{"ns/key", []float64{10}, []float64{10}, 0, 0, false},
+		{"Mops/s", []float64{10}, []float64{12}, 20, -20, false},
+		{"ns/key", []float64{0}, []float64{1}, math.Inf(1), math.Inf(1), false},
+		{"ns/key", []float64{9.9, 10, 10.1, 10, 10}, []float64{19.9, 20, 20.1, 20, 20}, 100, 100, true},
+	}
+	for _, tt := range tests {
+		old := newRun(tt.metric, map[result.Key][]float64{k: tt.old})
+		new := newRun(tt.metric, map[result.Key][]float64{k: tt.new, other: {1}})
+		deltas := Compare(old, new, []string{tt.metric})
+		if len(deltas) != 1 {
+			t.Fatalf("%s %v -> %v: got %d deltas, want 1", tt.metric, tt.old, tt.new, len(deltas))
+		}
+		d := deltas[0]
+		if math.Abs(d.Change-tt.wantChange) > 1e-9 && !(math.IsInf(d.Change, 1) && math.IsInf(tt.wantChange, 1)) {
+			t.Errorf("%s %v -> %v: change = %v, want %v", tt.metric, tt.old, tt.new, d.Change, tt.wantChange)
+		}
+		if math.Abs(d.Regression-tt.wantRegression) > 1e-9 && !(math.IsInf(d.Regression, 1) && math.IsInf(tt.wantRegression, 1)) {
+			t.Errorf("%s %v -> %v: regression = %v, want %v", tt.metric, tt.old, tt.new, d.Regression, tt.wantRegression)
+		}
+		if d.Significant() != tt.significant {
+			t.Errorf("%s %v -> %v: significant = %v (p=%v), want %v", tt.metric, tt.old, tt.new, d.Significant(), d.P, tt.significant)
+		}
+	}
+}
+
+func TestRuleMatches(t *testing.T) {
+	d := &Delta{Key: result.Key{Benchmark: "BenchmarkU64FullReadsMisses", Map: "robin", Size: 1000}, Metric: "ns/key"}
+	tests := []struct {
+		rule Rule
+		want bool
+	}{
+		{Rule{}, true},
+		{Rule{Benchmark: "U64FullReadsMisses"}, true},
+		{Rule{Benchmark: "BenchmarkU64FullReadsMisses"}, true},
+		{Rule{Benchmark: "U32FullReadsMisses"}, false},
+		{Rule{Scenario: "FullReadsMisses"}, true},
+		{Rule{Scenario: "FullReads"}, false},
+		{Rule{Map: "robin", Metric: "ns/key"}, true},
+		{Rule{Map: "robin", Metric: "B/key"}, false},
+		{Rule{Scenario: "FullReadsMisses", Map: "swiss"}, false},
+	}
+	for _, tt := range tests {
+		if got := tt.rule.matches(d, "FullReadsMisses"); got != tt.want {
+			t.Errorf("%+v matches = %v, want %v", tt.rule, got, tt.want)
+		}
+	}
+}
+
+func TestCheck(t *testing.T) {
+	std := result.Key{Benchmark: "BenchmarkU64FullReads", Map: "std", Size: 1000}
+	robin := result.Key{Benchmark: "BenchmarkU64FullReads", Map: "robin", Size: 1000}
+	misses := result.Key{Benchmark: "BenchmarkU64FullReadsMisses", Map: "std", Size: 1000}
+	stable := []float64{10, 10.1, 9.9, 10, 10.2}
+	slower := []float64{13, 13.1, 12.9, 13, 13.2}
+	noisy := []float64{10, 14, 9, 12, 8}
+	forty := 40.0
+
+	tests := []struct {
+		name          string
+		cfg           GateConfig
+		old, new      map[result.Key][]float64
+		wantViolation int
+		wantUntested  int
+	}{
+		{"significant regression", GateConfig{Metrics: []string{"ns/key"}, Threshold: 15},
+			map[result.Key][]float64{std: stable}, map[result.Key][]float64{std: slower}, 1, 0},
+		{"below threshold", GateConfig{Metrics: []string{"ns/key"}, Threshold: 40},
+			map[result.Key][]float64{std: stable}, map[result.Key][]float64{std: slower}, 0, 0},
+		{"not significant", GateConfig{Metrics: []string{"ns/key"}, Threshold: 5},
+			map[result.Key][]float64{std: stable}, map[result.Key][]float64{std: noisy}, 0, 0},
+		{"single sample is untested", GateConfig{Metrics: []string{"ns/key"}, Threshold: 15},
+			map[result.Key][]float64{std: {10}}, map[result.Key][]float64{std: {13}}, 0, 1},
+		{"ignored map", GateConfig{Metrics: []string{"ns/key"}, Threshold: 15, Allow: []Rule{{Map: "robin"}}},
+			map[result.Key][]float64{std: stable, robin: stable}, map[result.Key][]float64{std: slower, robin: slower}, 1, 0},
+		{"relaxed threshold", GateConfig{Metrics: []string{"ns/key"}, Threshold: 15, Allow: []Rule{{Scenario: "FullReads", Threshold: &forty}}},
+			map[result.Key][]float64{std: stable, misses: stable}, map[result.Key][]float64{std: slower, misses: slower}, 1, 0},
+		{"selected benchmarks", GateConfig{Metrics: []string{"ns/key"}, Threshold: 15, Benchmarks: []string{"FullReadsMisses"}},
+			map[result.Key][]float64{std: stable, misses: stable}, map[result.Key][]float64{std: slower, misses: slower}, 1, 0},
+	}
+	for _, tt := range tests {
+		violations, untested := tt.cfg.Check(newRun("ns/key", tt.old), newRun("ns/key", tt.new))
+		if len(violations) != tt.wantViolation || len(untested) != tt.wantUntested {
+			t.Errorf("%s: got %d violations and %d untested, want %d and %d",
+				tt.name, len(violations), len(untested), tt.wantViolation, tt.wantUntested)
+		}
+	}
+}
@@ -0,0 +1,140 @@
+package compare
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"bench-hashmaps/result"
+)
+
+// Rule relaxes the regression gate for noisy maps or scenarios. A rule matches a delta,
+// if all of its non empty fields match. Without a threshold the matching deltas are ignored,
+// otherwise the threshold of the rule replaces the default threshold.
+type Rule struct {
+	// Benchmark is the benchmark name with or without the Benchmark prefix, e.g. U64FullReadsMisses.
+	Benchmark string `json:"benchmark,omitempty"`
+	// Scenario is the scenario name independent of the key type, e.g. FullReadsMisses.
+	Scenario string `json:"scenario,omitempty"`
+	// Map is the name of the map, e.g. robin.
+	Map string `json:"map,omitempty"`
+	// Metric is the name of the metric, e.g. ns/op.
+	Metric string `json:"metric,omitempty"`
+	// Threshold is the allowed regression in percent.
+	Threshold *float64 `json:"threshold,omitempty"`
+}
+
+// GateConfig configures the performance regression gate.
+type GateConfig struct {
+	// Metrics are the checked metrics.
+	Metrics []string `json:"metrics"`
+	// Threshold is the allowed regression in percent.
+	Threshold float64 `json:"threshold"`
+	// Benchmarks limits the check to the given benchmarks or scenarios, all if empty.
+	Benchmarks []string `json:"benchmarks,omitempty"`
+	// Allow contains the exceptions for noisy maps and scenarios.
+	Allow []Rule `json:"allow,omitempty"`
+}
+
+// DefaultGateConfig returns the configuration used without a config file.
+func DefaultGateConfig() GateConfig {
+	return GateConfig{
+		Metrics:   []string{"ns/key"},
+		Threshold: 15,
+	}
+}
+
+// LoadGateConfig reads a JSON gate configuration. Unset fields keep their default.
+func LoadGateConfig(path string) (GateConfig, error) {
+	cfg := DefaultGateConfig()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return cfg, err
+	}
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		return cfg, fmt.Errorf("%s: %w", path, err)
+	}
+	if cfg.Threshold < 0 {
+		return cfg, fmt.Errorf("%s: negative threshold %v", path, cfg.Threshold)
+	}
+	return cfg, nil
+}
+
+// Violation is a regression exceeding the allowed threshold.
+type Violation struct {
+	Delta
+	Threshold float64
+}
+
+func (v *Violation) String() string {
+	return fmt.Sprintf("%s/%s-%d: %s regressed by %.2f%% (allowed %.2f%%, p=%.3f n=%d+%d)",
+		strings.TrimPrefix(v.Benchmark, "Benchmark"), v.Map, v.Size, v.Metric,
+		v.Regression, v.Threshold, v.P, v.Old.N, v.New.N)
+}
+
+func (r *Rule) matches(d *Delta, scenario string) bool {
+	return (r.Benchmark == "" || strings.TrimPrefix(r.Benchmark, "Benchmark") == strings.TrimPrefix(d.Benchmark, "Benchmark")) &&
+		(r.Scenario == "" || r.Scenario == scenario) &&
+		(r.Map == "" || r.Map == d.Map) &&
+		(r.Metric == "" || r.Metric == d.Metric)
+}
+
+func (cfg *GateConfig) selected(d *Delta, scenario string) bool {
+	if len(cfg.Benchmarks) == 0 {
+		return true
+	}
+	for _, b := range cfg.Benchmarks {
+		if b == scenario || strings.TrimPrefix(b, "Benchmark") == strings.TrimPrefix(d.Benchmark, "Benchmark") {
+			return true
+		}
+	}
+	return false
+}
+
+// MinSamples is the number of samples per sub-benchmark in both runs, which are required for a significance
+// test. A single run can not distinguish a regression from noise.
+const MinSamples = 4
+
+// Check compares the run against the baseline and returns all significant regressions above the threshold.
+// Regressions above the threshold, which can not be tested for significance because one of the runs contains
+// less than MinSamples samples, are returned as untested. They fail the gate on the threshold alone, because
+// a baseline without repetitions must not let every regression pass.
+func (cfg *GateConfig) Check(baseline, run *result.Run) (violations, untested []Violation) {
+	deltas := Compare(baseline, run, cfg.Metrics)
+	for i := range deltas {
+		d := &deltas[i]
+		_, scenario := result.SplitBenchmark(d.Benchmark)
+		if !cfg.selected(d, scenario) {
+			continue
+		}
+		threshold, ignored := cfg.Threshold, false
+		for j := range cfg.Allow {
+			rule := &cfg.Allow[j]
+			if !rule.matches(d, scenario) {
+				continue
+			}
+			if rule.Threshold == nil {
+				ignored = true
+				break
+			}
+			threshold = *rule.Threshold
+		}
+		if ignored || d.Regression <= threshold {
+			continue
+		}
+		v := Violation{Delta: *d, Threshold: threshold}
+		switch {
+		case !testable(d):
+			untested = append(untested, v)
+		case d.Significant():
+			violations = append(violations, v)
+		}
+	}
+	return violations, untested
+}
+
+// testable reports whether the samples are large enough for a meaningful significance test.
+func testable(d *Delta) bool {
+	return d.Old.N >= MinSamples && d.New.N >= MinSamples
+}
@@ -0,0 +1,602 @@
+package bench_test
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"bench-hashmaps/cpucache"
+	"bench-hashmaps/dataset"
+)
+
+// benchConfig configures a benchmark run. The values are taken in the following order,
+// later ones override earlier ones: defaults, environment variables, config file and flags.
+type benchConfig struct {
+	// Maps are the names of the benchmarked maps, see createMap.
+	Maps []string `json:"maps,omitempty"`
+	// Sizes are the numbers of elements (n).
+	Sizes []int `json:"sizes,omitempty"`
+	// FloodSizes are the numbers of elements of BenchmarkFlood, whose colliding keys make some maps quadratic.
+	FloodSizes []int `json:"floodSizes,omitempty"`
+	// SmallSizes are the numbers of elements of the small map benchmarks, e.g. BenchmarkU64SmallInserts.
+	SmallSizes []int `json:"smallSizes,omitempty"`
+	// SmallMaps is the number of maps of the small map benchmarks, fewer for the larger sizes.
+	SmallMaps int `json:"smallMaps,omitempty"`
+	// PopulationMaps are the numbers of maps (M) of BenchmarkPopulation.
+	PopulationMaps []int `json:"populationMaps,omitempty"`
+	// PopulationEntries are the numbers of entries per map (k) of BenchmarkPopulation.
+	PopulationEntries []int `json:"populationEntries,omitempty"`
+	// ColdSizes are the numbers of elements of BenchmarkColdReads, which evicts the caches before each batch of lookups.
+	ColdSizes []int `json:"coldSizes,omitempty"`
+	// ColdBatch is the number of lookups of BenchmarkColdReads after each eviction of the caches.
+	ColdBatch int `json:"coldBatch,omitempty"`
+	// CacheSizes are the sizes of the data caches by level, e.g. 48K,2M,105M. They override the caches
+	// detected from the sysfs, which define the sizes of the cache sweep, e.g. BenchmarkU64CacheSweep.
+	CacheSizes string `json:"cacheSizes,omitempty"`
+	// HashBench enables the hash function benchmarks, e.g. BenchmarkU64HashRandom, which are otherwise only run
+	// if their scenario is selected.
+	HashBench bool `json:"hashBench,omitempty"`
+	// Scenarios limits the benchmarks to the given scenarios, e.g. FullReads, all if empty.
+	Scenarios []string `json:"scenarios,omitempty"`
+	// KeyTypes limits the benchmarks to the given key types, e.g. U64, all if empty.
+	KeyTypes []string `json:"keyTypes,omitempty"`
+	// Repetitions is the number of independent runs of each benchmark (go test -count).
+	Repetitions int `json:"repetitions,omitempty"`
+	// Seed is the seed of the random number generator.
+	Seed *int64 `json:"seed,omitempty"`
+	// Shuffle randomizes the execution order of the maps.
+	Shuffle *bool `json:"shuffle,omitempty"`
+	// Benchtime is the go test -benchtime value.
+	Benchtime string `json:"benchtime,omitempty"`
+	// Format is the output format, text or json. With json, the results are additionally
+	// written as JSON document.
+	Format string `json:"format,omitempty"`
+	// OutDir is the directory of the JSON document.
+	OutDir string `json:"outDir,omitempty"`
+	// Specs is the glob pattern of the workload spec files of BenchmarkSpec.
+	Specs string `json:"specs,omitempty"`
+	// Traces is the glob pattern of the trace files of BenchmarkTrace.
+	Traces string `json:"traces,omitempty"`
+	// Dataset is the path of a key dataset, which replaces the generated keys of all scenarios.
+	Dataset string `json:"dataset,omitempty"`
+	// DatasetFormat is the format of the dataset: lines, u32 or u64 (default: by file extension).
+	DatasetFormat string `json:"datasetFormat,omitempty"`
+	// KeyPattern is the pattern of the integer keys, which replaces the dense and random keys, see keyPatterns.
+	KeyPattern string `json:"keyPattern,omitempty"`
+	// JSONOut is the path of the JSON document, it takes precedence over OutDir.
+	JSONOut string `json:"jsonOut,omitempty"`
+}
+
+// cfg is the configuration of the current benchmark run, it is set up by TestMain.
+var cfg = defaultConfig()
+
+var keyTypeNames = []string{"U32", "U64", "UUID"}
+
+var (
+	flagConfig = flag.String("config", "", "JSON file with named benchmark configurations")
+	flagName   = flag.String("name", "default", "name of the configuration in the config file")
+)
+
+// option is a setting, which can be set by an environment variable, a flag or both.
+type option struct {
+	// env is the name of the environment variable, flag is the name of the flag, empty if not supported.
+	env, flag, usage string
+	set              func(c *benchConfig, v string) error
+}
+
+// options are the settings of the environment variables and flags, the config file sets the fields directly.
+var options = []option{
+	{"MAPS", "maps", "benchmarked maps, separated by spaces or commas",
+		func(c *benchConfig, v string) error { c.Maps = splitList(v); return nil }},
+	{"RANGES", "sizes", "benchmarked sizes (n), separated by spaces or commas",
+		func(c *benchConfig, v string) (err error) { c.Sizes, err = parseSizes(v); return err }},
+	{"FLOOD_SIZES", "flood-sizes", "sizes (n) of the adversarial keys of BenchmarkFlood",
+		func(c *benchConfig, v string) (err error) { c.FloodSizes, err = parseSizes(v); return err }},
+	{"SMALL_SIZES", "small-sizes", "sizes (n) of the small map benchmarks, separated by spaces or commas",
+		func(c *benchConfig, v string) (err error) { c.SmallSizes, err = parseSmallSizes(v); return err }},
+	{"SMALL_MAPS", "small-maps", "number of maps of the small map benchmarks",
+		func(c *benchConfig, v string) (err error) { c.SmallMaps, err = parseNumber(v); return err }},
+	{"POPULATION_MAPS", "population-maps", "numbers of maps (M) of BenchmarkPopulation",
+		func(c *benchConfig, v string) (err error) { c.PopulationMaps, err = parseSizes(v); return err }},
+	{"POPULATION_ENTRIES", "population-entries", "numbers of entries per map (k) of BenchmarkPopulation",
+		func(c *benchConfig, v string) (err error) { c.PopulationEntries, err = parseSizes(v); return err }},
+	{"COLD_SIZES", "cold-sizes", "sizes (n) of the warm and cold cache reads of BenchmarkColdReads",
+		func(c *benchConfig, v string) (err error) { c.ColdSizes, err = parseSizes(v); return err }},
+	{"COLD_BATCH", "cold-batch", "number of lookups of BenchmarkColdReads after each eviction of the caches",
+		func(c *benchConfig, v string) (err error) { c.ColdBatch, err = parseNumber(v); return err }},
+	{"CACHE_SIZES", "cache-sizes", "data cache sizes by level, e.g. 48K,2M,105M (default: detected)",
+		func(c *benchConfig, v string) error { c.CacheSizes = v; return nil }},
+	{"HASH_BENCH", "hash-bench", "run the hash function benchmarks (true or false)",
+		func(c *benchConfig, v string) error {
+			b, err := parseBool(v)
+			if err == nil {
+				c.HashBench = *b
+			}
+			return err
+		}},
+	{"", "scenarios", "benchmarked scenarios, e.g. FullReads,RandomFullInserts",
+		func(c *benchConfig, v string) error { c.Scenarios = splitList(v); return nil }},
+	{"", "keytypes", "benchmarked key types: U32, U64, UUID",
+		func(c *benchConfig, v string) error { c.KeyTypes = splitList(v); return nil }},
+	{"COUNT", "reps", "number of independent repetitions of each benchmark",
+		func(c *benchConfig, v string) (err error) { c.Repetitions, err = parseNumber(v); return err }},
+	{"SEED", "seed", "seed of the random number generator",
+		func(c *benchConfig, v string) (err error) { c.Seed, err = parseSeed(v); return err }},
+	{"SHUFFLE", "shuffle", "randomize the execution order of the maps (true or false)",
+		func(c *benchConfig, v string) (err error) { c.Shuffle, err = parseBool(v); return err }},
+	{"", "format", "output format: text or json",
+		func(c *benchConfig, v string) error { c.Format = v; return nil }},
+	{"", "outdir", "directory of the JSON result document",
+		func(c *benchConfig, v string) error { c.OutDir = v; return nil }},
+	{"SPECS", "specs", "glob pattern of the workload spec files",
+		func(c *benchConfig, v string) error { c.Specs = v; return nil }},
+	{"TRACES", "traces", "glob pattern of the replayed trace files",
+		func(c *benchConfig, v string) error { c.Traces = v; return nil }},
+	{"DATASET", "dataset", "key dataset file, which replaces the generated keys",
+		func(c *benchConfig, v string) error { c.Dataset = v; return nil }},
+	{"", "dataset-format", "format of the key dataset: lines, u32 or u64 (default: by file extension)",
+		func(c *benchConfig, v string) error { c.DatasetFormat = v; return nil }},
+	{"KEY_PATTERN", "key-pattern", "pattern of the integer keys: " + strings.Join(keyPatterns, ", "),
+		func(c *benchConfig, v string) error { c.KeyPattern = v; return nil }},
+	{"JSON_OUT", "", "",
+		func(c *benchConfig, v string) error { c.JSONOut = v; c.Format = "json"; return nil }},
+}
+
+// flagValues contains the values of the flags of the options by flag name.
+var flagValues = registerFlags()
+
+func registerFlags() map[string]*string {
+	values := make(map[string]*string)
+	for _, o := range options {
+		if o.flag != "" {
+			values[o.flag] = flag.String(o.flag, "", o.usage)
+		}
+	}
+	return values
+}
+
+func defaultConfig() benchConfig {
+	shuffle := true
+	return benchConfig{
+		Maps: []string{"std", "robin", "robinLowLoad", "unordered", "swiss", "generic", "flat", "hopscotch", "hopscotchLowLoad"},
+		Sizes: []int{50000, 100000, 200000, 400000, 600000, 800000, 1000000, 1200000, 1400000,
+			1600000, 1800000, 2000000, 2200000, 2400000, 2600000, 2800000, 3000000},
+		FloodSizes:        []int{1000, 10000},
+		SmallSizes:        []int{0, 1, 2, 4, 8, 16, 32, 64, 128, 256},
+		SmallMaps:         1000000,
+		PopulationMaps:    []int{100000, 1000000},
+		PopulationEntries: []int{1, 8},
+		ColdSizes:         []int{10000, 100000, 1000000},
+		ColdBatch:         1000,
+		Repetitions:       1,
+		Shuffle:           &shuffle,
+		Benchtime:         "2x",
+		Format:            "text",
+		Specs:             "workloads/*.json",
+		Traces:            "traces/*.trace",
+	}
+}
+
+// splitList splits a list separated by spaces or commas.
+func splitList(s string) []string {
+	return strings.FieldsFunc(s, func(r rune) bool { return r == ' ' || r == ',' || r == '\t' })
+}
+
+func parseSizes(s string) ([]int, error) {
+	var sizes []int
+	for _, item := range splitList(s) {
+		n, err := strconv.Atoi(item)
+		if err != nil || n <= 0 {
+			return nil, fmt.Errorf("invalid size %q, expected a positive integer", item)
+		}
+		sizes = append(sizes, n)
+	}
+	return sizes, nil
+}
+
+// parseSmallSizes parses the sizes of the small map benchmarks, which include empty maps.
+func parseSmallSizes(s string) ([]int, error) {
+	var sizes []int
+	for _, item := range splitList(s) {
+		n, err := strconv.Atoi(item)
+		if err != nil || n < 0 {
+			return nil, fmt.Errorf("invalid size %q, expected a non-negative integer", item)
+		}
+		sizes = append(sizes, n)
+	}
+	return sizes, nil
+}
+
+func parseNumber(s string) (int, error) {
+	n, err := strconv.Atoi(s)
+	if err != nil {
+		return 0, fmt.Errorf("invalid number %q", s)
+	}
+	return n, nil
+}
+
+func parseSeed(s string) (*int64, error) {
+	x, err := strconv.ParseInt(s, 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid seed %q, expected an integer", s)
+	}
+	return &x, nil
+}
+
+func parseBool(s string) (*bool, error) {
+	if s == "0" {
+		s = "false"
+	} else if s == "1" {
+		s = "true"
+	}
+	b, err := strconv.ParseBool(s)
+	if err != nil {
+		return nil, fmt.Errorf("invalid value %q, expected true or false", s)
+	}
+	return &b, nil
+}
+
+// applyEnv applies the environment variables of the options, e.g. RANGES, MAPS and COUNT.
+func (c *benchConfig) applyEnv() error {
+	for _, o := range options {
+		if o.env == "" {
+			continue
+		}
+		if v := os.Getenv(o.env); v != "" {
+			if err := o.set(c, v); err != nil {
+				return fmt.Errorf("%s: %w", o.env, err)
+			}
+		}
+	}
+	return nil
+}
+
+// applyFile applies the named configuration of the config file.
+func (c *benchConfig) applyFile(path, name string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	var configs map[string]json.RawMessage
+	if err := json.Unmarshal(data, &configs); err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+	raw, ok := configs[name]
+	if !ok {
+		names := make([]string, 0, len(configs))
+		for n := range configs {
+			names = append(names, n)
+		}
+		return fmt.Errorf("%s: configuration %q not found, available: %s", path, name, strings.Join(names, ", "))
+	}
+	dec := json.NewDecoder(strings.NewReader(string(raw)))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(c); err != nil {
+		return fmt.Errorf("%s: configuration %q: %w", path, name, err)
+	}
+	return nil
+}
+
+// applyFlags applies the command line flags of the options.
+func (c *benchConfig) applyFlags() error {
+	for _, o := range options {
+		if o.flag == "" {
+			continue
+		}
+		if v := *flagValues[o.flag]; v != "" {
+			if err := o.set(c, v); err != nil {
+				return fmt.Errorf("-%s: %w", o.flag, err)
+			}
+		}
+	}
+	return nil
+}
+
+var scenarioPattern = regexp.MustCompile(`^[A-Za-z0-9_]+$`)
+
+// validate checks the configuration for invalid values.
+func (c *benchConfig) validate() error {
+	if len(c.Maps) == 0 {
+		return fmt.Errorf("no maps configured")
+	}
+	for _, m := range c.Maps {
+		if !contains(knownMaps, m) {
+			return fmt.Errorf("unknown map %q, available: %s", m, strings.Join(knownMaps, " "))
+		}
+	}
+	if len(c.Sizes) == 0 {
+		return fmt.Errorf("no sizes configured")
+	}
+	for _, n := range c.Sizes {
+		if n <= 0 {
+			return fmt.Errorf("invalid size %d, expected a positive integer", n)
+		}
+	}
+	for _, n := range c.FloodSizes {
+		if n <= 0 {
+			return fmt.Errorf("invalid flood size %d, expected a positive integer", n)
+		}
+	}
+	for _, n := range c.SmallSizes {
+		if n < 0 {
+			return fmt.Errorf("invalid small size %d, expected a non-negative integer", n)
+		}
+	}
+	if c.SmallMaps < 1 {
+		return fmt.Errorf("invalid number of small maps %d, expected at least 1", c.SmallMaps)
+	}
+	for _, n := range append(append([]int(nil), c.PopulationMaps...), c.PopulationEntries...) {
+		if n <= 0 {
+			return fmt.Errorf("invalid population size %d, expected a positive integer", n)
+		}
+	}
+	for _, n := range c.ColdSizes {
+		if n <= 0 {
+			return fmt.Errorf("invalid cold size %d, expected a positive integer", n)
+		}
+	}
+	if c.ColdBatch < 1 {
+		return fmt.Errorf("invalid cold batch %d, expected at least 1", c.ColdBatch)
+	}
+	if c.CacheSizes != "" {
+		if _, err := cpucache.ParseList(c.CacheSizes); err != nil {
+			return err
+		}
+	}
+	for _, s := range c.Scenarios {
+		if !scenarioPattern.MatchString(s) {
+			return fmt.Errorf("invalid scenario %q", s)
+		}
+	}
+	for _, kt := range c.KeyTypes {
+		if !contains(keyTypeNames, kt) {
+			return fmt.Errorf("unknown key type %q, available: %s", kt, strings.Join(keyTypeNames, " "))
+		}
+	}
+	if c.Repetitions < 1 {
+		return fmt.Errorf("invalid number of repetitions %d, expected at least 1", c.Repetitions)
+	}
+	if _, err := filepath.Match(c.Specs, ""); err != nil {
+		return fmt.Errorf("invalid specs pattern %q: %w", c.Specs, err)
+	}
+	if _, err := filepath.Match(c.Traces, ""); err != nil {
+		return fmt.Errorf("invalid traces pattern %q: %w", c.Traces, err)
+	}
+	if _, err := dataset.ParseFormat(c.DatasetFormat, c.Dataset); err != nil {
+		return err
+	}
+	if c.KeyPattern != "" && !contains(keyPatterns, c.KeyPattern) {
+		return fmt.Errorf("unknown key pattern %q, available: %s", c.KeyPattern, strings.Join(keyPatterns, " "))
+	}
+	if c.KeyPattern != "" && c.KeyPattern != "random" && c.Dataset != "" {
+		return fmt.Errorf("key pattern %s and dataset %s can not be combined", c.KeyPattern, c.Dataset)
+	}
+	// the hits and misses of U32 keys share the 2^20 multiples of the stride
+	if c.KeyPattern == "strided" && (len(c.KeyTypes) == 0 || contains(c.KeyTypes, "U32")) {
+		for _, n := range c.Sizes {
+			if 2*n >= 1<<32/patternStride {
+				return fmt.Errorf("key pattern strided provides %d distinct U32 keys, but size %d requires %d keys for hits and misses, select -keytypes U64 or smaller sizes",
+					1<<32/patternStride-1, n, 2*n)
+			}
+		}
+	}
+	// the 32 bit low16 keys count up only the high 16 bits, the misses are generated like the hits
+	if c.KeyPattern == "low16" && (len(c.KeyTypes) == 0 || contains(c.KeyTypes, "U32")) {
+		for _, n := range c.Sizes {
+			if 2*n > 1<<16 {
+				return fmt.Errorf("key pattern low16 provides %d ordered U32 keys, but size %d requires %d keys for hits and misses, select -keytypes U64 or smaller sizes",
+					1<<16, n, 2*n)
+			}
+		}
+	}
+	if c.Format != "text" && c.Format != "json" {
+		return fmt.Errorf("unknown output format %q, available: text json", c.Format)
+	}
+	return nil
+}
+
+func contains(list []string, s string) bool {
+	for _, x := range list {
+		if x == s {
+			return true
+		}
+	}
+	return false
+}
+
+// nestedBenchmarks are the benchmarks, whose sub-benchmarks are named by key type and scenario, e.g.
+// BenchmarkColdReads/U64FullReadsCold. They always match the -bench pattern and select their
+// sub-benchmarks with selects.
+var nestedBenchmarks = []string{"Spec", "Trace", "Flood", "Population", "ColdReads"}
+
+// benchPattern returns the go test -bench pattern of the selected scenarios and key types.
+func (c *benchConfig) benchPattern() string {
+	keyTypes, scenarios := ".*", ".*"
+	if len(c.KeyTypes) > 0 {
+		keyTypes = "(" + strings.Join(c.KeyTypes, "|") + ")"
+	}
+	if len(c.Scenarios) > 0 {
+		scenarios = "(" + strings.Join(c.Scenarios, "|") + ")"
+	}
+	return "^Benchmark(" + keyTypes + scenarios + "|" + strings.Join(nestedBenchmarks, "|") + ")$"
+}
+
+// selects reports whether a sub-benchmark of the nested benchmarks with the key type and one of the
+// names is selected by the key types and scenarios. An empty key type is selected by all key types.
+func (c *benchConfig) selects(keyType string, names ...string) bool {
+	if keyType != "" && len(c.KeyTypes) > 0 && !contains(c.KeyTypes, keyType) {
+		return false
+	}
+	if len(c.Scenarios) == 0 {
+		return true
+	}
+	for _, name := range names {
+		if contains(c.Scenarios, name) {
+			return true
+		}
+	}
+	return false
+}
+
+// jsonPath returns the path of the JSON document or an empty string for the text format.
+func (c *benchConfig) jsonPath(start time.Time) string {
+	if c.Format != "json" {
+		return ""
+	}
+	if c.JSONOut != "" {
+		return c.JSONOut
+	}
+	dir := c.OutDir
+	if dir == "" {
+		dir = "."
+	}
+	name := *flagName
+	return filepath.Join(dir, fmt.Sprintf("%s_%s.json", name, start.Format("2006-01-02_15-04-05")))
+}
+
+// loadDataset loads the key dataset, which replaces the generated keys. If neither key types nor
+// specific benchmarks (go test -bench) are selected, only the benchmarks of the key types matching
+// the dataset are run.
+func (c *benchConfig) loadDataset() error {
+	if c.Dataset == "" {
+		return nil
+	}
+	format, err := dataset.ParseFormat(c.DatasetFormat, c.Dataset)
+	if err != nil {
+		return err
+	}
+	set, err := dataset.Load(c.Dataset, format)
+	if err != nil {
+		return fmt.Errorf("dataset: %w", err)
+	}
+	set.Shuffle(rand.New(rand.NewSource(*c.Seed)))
+
+	compatible := []string{"UUID"}
+	if set.IsInt() {
+		compatible = []string{"U32", "U64"}
+	}
+	if bench := flag.Lookup("test.bench"); len(c.KeyTypes) == 0 && bench != nil && bench.Value.String() == "." {
+		c.KeyTypes = compatible
+	}
+	for _, kt := range c.KeyTypes {
+		if !contains(compatible, kt) {
+			return fmt.Errorf("dataset %s with %s keys can not be used for key type %s, compatible: %s",
+				c.Dataset, format, kt, strings.Join(compatible, " "))
+		}
+	}
+	keyTypes := c.KeyTypes
+	if len(keyTypes) == 0 {
+		keyTypes = compatible
+	}
+	// the hits are taken from the front and the misses from the back of the dataset
+	for _, kt := range keyTypes {
+		distinct := distinctKeys(set, kt)
+		for _, n := range c.Sizes {
+			if 2*n > distinct {
+				return fmt.Errorf("dataset %s contains %d distinct %s keys, but size %d requires %d keys for hits and misses",
+					c.Dataset, distinct, kt, n, 2*n)
+			}
+		}
+	}
+	keySet = set
+	return nil
+}
+
+// distinctKeys returns the number of distinct keys of the dataset, which datasetInts provides for the
+// key type. The integer keys are truncated to 32 bits for U32 and 0 is not used as key.
+func distinctKeys(set *dataset.Set, keyType string) int {
+	if !set.IsInt() {
+		return set.Len()
+	}
+	seen := make(map[uint64]bool, len(set.Ints))
+	for _, x := range set.Ints {
+		if keyType == "U32" {
+			x = uint64(uint32(x))
+		}
+		if x != 0 {
+			seen[x] = true
+		}
+	}
+	return len(seen)
+}
+
+// caches are the data caches of the cache sweep, nil if they are neither configured nor detected.
+var caches []cpucache.Cache
+
+// detectCaches sets the configured cache sizes or the caches detected from the sysfs.
+func (c *benchConfig) detectCaches() {
+	if c.CacheSizes != "" {
+		// validated before
+		caches, _ = cpucache.ParseList(c.CacheSizes)
+		return
+	}
+	caches, _ = cpucache.Detect()
+}
+
+// setupConfig builds the configuration and applies it to the go test flags,
+// which were not explicitly set on the command line.
+func setupConfig() error {
+	c := defaultConfig()
+	if err := c.applyEnv(); err != nil {
+		return err
+	}
+	if *flagConfig != "" {
+		if err := c.applyFile(*flagConfig, *flagName); err != nil {
+			return err
+		}
+	}
+	if err := c.applyFlags(); err != nil {
+		return err
+	}
+	if err := c.validate(); err != nil {
+		return err
+	}
+	if c.Seed == nil {
+		seed := time.Now().UnixNano()
+		c.Seed = &seed
+	}
+
+	if err := c.loadDataset(); err != nil {
+		return err
+	}
+	c.detectCaches()
+
+	explicit := make(map[string]bool)
+	flag.Visit(func(f *flag.Flag) { explicit[f.Name] = true })
+	set := func(name, value string) error {
+		if explicit[name] || flag.Lookup(name) == nil {
+			return nil
+		}
+		return flag.Set(name, value)
+	}
+	if err := set("test.count", strconv.Itoa(c.Repetitions)); err != nil {
+		return err
+	}
+	if err := set("test.benchtime", c.Benchtime); err != nil {
+		return fmt.Errorf("invalid benchtime %q: %w", c.Benchtime, err)
+	}
+	if len(c.Scenarios) > 0 || len(c.KeyTypes) > 0 {
+		bench := flag.Lookup("test.bench")
+		if bench != nil && bench.Value.String() == "" {
+			return fmt.Errorf("scenarios and key types require benchmarks to be enabled (go test -bench)")
+		}
+		// -bench=. of run.sh selects all benchmarks and is narrowed, other explicit patterns are kept
+		// and only the sub-benchmarks of the nested benchmarks are selected
+		if bench != nil && bench.Value.String() == "." {
+			delete(explicit, "test.bench")
+		}
+		if err := set("test.bench", c.benchPattern()); err != nil {
+			return err
+		}
+	}
+	mapOrder = rand.New(rand.NewSource(*c.Seed))
+	cfg = c
+	return nil
+}
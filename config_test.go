@@ -0,0 +1,203 @@
+package bench_test
+
+import (
+	"encoding/binary"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+func TestConfigPrecedence(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "bench.json")
+	file := `{"test": {"sizes": [2000, 3000], "repetitions": 3, "keyTypes": ["U64"]}}`
+	if err := os.WriteFile(path, []byte(file), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("MAPS", "std swiss")
+	t.Setenv("RANGES", "1000")
+	t.Setenv("COUNT", "2")
+	t.Setenv("SHUFFLE", "1")
+	if err := flag.Set("reps", "4"); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = flag.Set("reps", "") })
+
+	c := defaultConfig()
+	if err := c.applyEnv(); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.applyFile(path, "test"); err != nil {
+		t.Fatal(err)
+	}
+	if err := c.applyFlags(); err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		field     string
+		got, want any
+	}{
+		{"maps from the environment", c.Maps, []string{"std", "swiss"}},
+		{"shuffle from the environment", *c.Shuffle, true},
+		{"sizes from the file", c.Sizes, []int{2000, 3000}},
+		{"key types from the file", c.KeyTypes, []string{"U64"}},
+		{"repetitions from the flag", c.Repetitions, 4},
+		{"default benchtime", c.Benchtime, "2x"},
+	}
+	for _, tt := range tests {
+		if fmt.Sprint(tt.got) != fmt.Sprint(tt.want) {
+			t.Errorf("%s = %v, want %v", tt.field, tt.got, tt.want)
+		}
+	}
+}
+
+func TestConfigErrors(t *testing.T) {
+	tests := []struct {
+		name  string
+		env   map[string]string
+		file  string
+		apply func(c *benchConfig)
+		want  string
+	}{
+		{name: "invalid env size", env: map[string]string{"RANGES": "1000 x"}, want: `RANGES: invalid size "x"`},
+		{name: "invalid env number", env: map[string]string{"COUNT": "many"}, want: `COUNT: invalid number "many"`},
+		{name: "invalid env bool", env: map[string]string{"SHUFFLE": "maybe"}, want: `SHUFFLE: invalid value "maybe"`},
+		{name: "unknown file field", file: `{"default": {"size": [1]}}`, want: `unknown field "size"`},
+		{name: "unknown file configuration", file: `{"nightly": {}}`, want: `configuration "default" not found`},
+		{name: "unknown map", apply: func(c *benchConfig) { c.Maps = []string{"btree"} }, want: `unknown map "btree"`},
+		{name: "no sizes", apply: func(c *benchConfig) { c.Sizes = nil }, want: "no sizes configured"},
+		{name: "negative size", apply: func(c *benchConfig) { c.Sizes = []int{-1} }, want: "invalid size -1"},
+		{name: "unknown key type", apply: func(c *benchConfig) { c.KeyTypes = []string{"U16"} }, want: `unknown key type "U16"`},
+		{name: "invalid scenario", apply: func(c *benchConfig) { c.Scenarios = []string{"Full.*"} }, want: `invalid scenario "Full.*"`},
+		{name: "no repetitions", apply: func(c *benchConfig) { c.Repetitions = 0 }, want: "invalid number of repetitions 0"},
+		{name: "unknown format", apply: func(c *benchConfig) { c.Format = "csv" }, want: `unknown output format "csv"`},
+		{name: "invalid cache sizes", apply: func(c *benchConfig) { c.CacheSizes = "2X" }, want: "2X"},
+		{name: "unknown key pattern", apply: func(c *benchConfig) { c.KeyPattern = "sparse" }, want: `unknown key pattern "sparse"`},
+		{name: "key pattern with dataset", apply: func(c *benchConfig) { c.KeyPattern, c.Dataset = "strided", "keys.txt" }, want: "can not be combined"},
+		{name: "low16 U32 keys", apply: func(c *benchConfig) { c.KeyPattern, c.Sizes = "low16", []int{40000} }, want: "ordered U32 keys"},
+		{name: "strided U32 keys", apply: func(c *benchConfig) { c.KeyPattern, c.Sizes = "strided", []int{1 << 20} }, want: "distinct U32 keys"},
+	}
+	for _, tt := range tests {
+		t.Run(strings.ReplaceAll(tt.name, " ", "_"), func(t *testing.T) {
+			for k, v := range tt.env {
+				t.Setenv(k, v)
+			}
+			c := defaultConfig()
+			err := c.applyEnv()
+			if err == nil && tt.file != "" {
+				path := filepath.Join(t.TempDir(), "bench.json")
+				if err := os.WriteFile(path, []byte(tt.file), 0o644); err != nil {
+					t.Fatal(err)
+				}
+				err = c.applyFile(path, "default")
+			}
+			if err == nil {
+				if tt.apply != nil {
+					tt.apply(&c)
+				}
+				err = c.validate()
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("error = %v, want %q", err, tt.want)
+			}
+		})
+	}
+	if c := defaultConfig(); c.validate() != nil {
+		t.Errorf("default configuration invalid: %v", c.validate())
+	}
+}
+
+func TestBenchSelection(t *testing.T) {
+	c := defaultConfig()
+	c.KeyTypes = []string{"U64"}
+	c.Scenarios = []string{"FullReads", "ColdReads"}
+	pattern := regexp.MustCompile(c.benchPattern())
+	tests := []struct {
+		name string
+		want bool
+	}{
+		{"BenchmarkU64FullReads", true},
+		{"BenchmarkU32FullReads", false},
+		{"BenchmarkU64FullReadsMisses", false},
+		{"BenchmarkColdReads", true},
+		{"BenchmarkFlood", true},
+	}
+	for _, tt := range tests {
+		if got := pattern.MatchString(tt.name); got != tt.want {
+			t.Errorf("pattern %s matches %s = %v, want %v", pattern, tt.name, got, tt.want)
+		}
+	}
+
+	subTests := []struct {
+		keyType string
+		names   []string
+		want    bool
+	}{
+		{"U64", []string{"RandomReads", "ColdReads"}, true},
+		{"U32", []string{"FullReads", "ColdReads"}, false},
+		{"U64", []string{"Collide", "Flood"}, false},
+		{"U64", []string{"FullReads", "Spec"}, true},
+		{"", []string{"service", "Trace"}, false},
+	}
+	for _, tt := range subTests {
+		if got := c.selects(tt.keyType, tt.names...); got != tt.want {
+			t.Errorf("selects(%q, %v) = %v, want %v", tt.keyType, tt.names, got, tt.want)
+		}
+	}
+	if all := defaultConfig(); !all.selects("U32", "Collide", "Flood") {
+		t.Error("default configuration does not select all sub-benchmarks")
+	}
+}
+
+func TestDatasetKeys(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "keys.u64")
+	var data []byte
+	for _, k := range []uint64{1, 2, 3, 0, 1<<32 | 1, 1<<32 | 2, 1<<32 | 3} {
+		data = binary.LittleEndian.AppendUint64(data, k)
+	}
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { keySet = nil })
+	tests := []struct {
+		keyTypes []string
+		size     int
+		want     string
+	}{
+		{[]string{"U64"}, 3, ""},
+		{[]string{"U64"}, 4, "contains 6 distinct U64 keys"},
+		{[]string{"U32"}, 1, ""},
+		{[]string{"U32"}, 2, "contains 3 distinct U32 keys"},
+		{nil, 2, "contains 3 distinct U32 keys"},
+	}
+	for _, tt := range tests {
+		c := defaultConfig()
+		seed := int64(1)
+		c.Seed, c.Dataset, c.KeyTypes, c.Sizes = &seed, path, tt.keyTypes, []int{tt.size}
+		err := c.loadDataset()
+		if (tt.want == "") != (err == nil) || (err != nil && !strings.Contains(err.Error(), tt.want)) {
+			t.Errorf("%v size %d: error = %v, want %q", tt.keyTypes, tt.size, err, tt.want)
+		}
+	}
+}
+
+func TestGenericVariants(t *testing.T) {
+	for _, name := range []string{"generic_fnv1a", "generic_wyhash", "generic_hashmaps"} {
+		c := defaultConfig()
+		c.Maps = []string{name}
+		if err := c.validate(); err != nil {
+			t.Errorf("%s: %v", name, err)
+			continue
+		}
+		m := createMap[uint64, uint64](16, name)
+		for k := uint64(1); k <= 100; k++ {
+			m.Put(k, k)
+		}
+		if v, ok := m.Get(42); !ok || v != 42 || m.Size() != 100 {
+			t.Errorf("%s: Get(42) = %d, %v with size %d", name, v, ok, m.Size())
+		}
+	}
+}
@@ -0,0 +1,160 @@
+// Package cpucache detects the data cache hierarchy of the CPU from the sysfs of Linux, e.g.
+// /sys/devices/system/cpu/cpu0/cache/index0/{level,type,size,coherency_line_size}.
+package cpucache
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// DefaultDir is the sysfs directory of the caches of the first CPU.
+const DefaultDir = "/sys/devices/system/cpu/cpu0/cache"
+
+// Cache is a data or unified cache level of the CPU.
+type Cache struct {
+	Level int    `json:"level"`
+	Type  string `json:"type"`
+	// Size is the size of the cache in bytes.
+	Size int64 `json:"size"`
+	// LineSize is the size of a cache line in bytes, 0 if unknown.
+	LineSize int `json:"lineSize,omitempty"`
+}
+
+// Name returns the short name of the level, e.g. L2.
+func (c Cache) Name() string {
+	return fmt.Sprintf("L%d", c.Level)
+}
+
+func (c Cache) String() string {
+	return c.Name() + " " + FormatSize(c.Size)
+}
+
+// Detect returns the data caches of the first CPU from DefaultDir.
+func Detect() ([]Cache, error) {
+	return Read(DefaultDir)
+}
+
+// Read returns the data and unified caches of the cache directory of a CPU ordered by level.
+// Instruction caches are skipped, so there is one cache per level.
+func Read(dir string) ([]Cache, error) {
+	indexes, err := filepath.Glob(filepath.Join(dir, "index*"))
+	if err != nil {
+		return nil, err
+	}
+	var caches []Cache
+	for _, index := range indexes {
+		c, err := readIndex(index)
+		if err != nil {
+			return nil, err
+		}
+		if c.Type == "Instruction" {
+			continue
+		}
+		caches = append(caches, c)
+	}
+	if len(caches) == 0 {
+		return nil, fmt.Errorf("no data caches found in %s", dir)
+	}
+	sort.Slice(caches, func(i, j int) bool { return caches[i].Level < caches[j].Level })
+	return caches, nil
+}
+
+func readIndex(dir string) (Cache, error) {
+	var c Cache
+	level, err := readFile(dir, "level")
+	if err != nil {
+		return c, err
+	}
+	if c.Level, err = strconv.Atoi(level); err != nil {
+		return c, fmt.Errorf("%s: invalid level %q", dir, level)
+	}
+	if c.Type, err = readFile(dir, "type"); err != nil {
+		return c, err
+	}
+	size, err := readFile(dir, "size")
+	if err != nil {
+		return c, err
+	}
+	if c.Size, err = ParseSize(size); err != nil {
+		return c, fmt.Errorf("%s: %w", dir, err)
+	}
+	// the line size is optional, e.g. it is missing on some virtual machines
+	if line, err := readFile(dir, "coherency_line_size"); err == nil {
+		c.LineSize, _ = strconv.Atoi(line)
+	}
+	return c, nil
+}
+
+func readFile(dir, name string) (string, error) {
+	b, err := os.ReadFile(filepath.Join(dir, name))
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(b)), nil
+}
+
+// ParseSize parses a cache size in bytes with an optional K, M or G suffix, e.g. 48K or 32M.
+func ParseSize(s string) (int64, error) {
+	s = strings.TrimSuffix(strings.TrimSpace(s), "B")
+	shift := 0
+	if s != "" {
+		switch s[len(s)-1] {
+		case 'K', 'k':
+			shift = 10
+		case 'M', 'm':
+			shift = 20
+		case 'G', 'g':
+			shift = 30
+		}
+	}
+	if shift > 0 {
+		s = s[:len(s)-1]
+	}
+	n, err := strconv.ParseInt(s, 10, 64)
+	if err != nil || n <= 0 {
+		return 0, fmt.Errorf("invalid cache size %q", s)
+	}
+	return n << shift, nil
+}
+
+// FormatSize formats a size in bytes with the largest exact K, M or G suffix, the inverse of ParseSize.
+func FormatSize(n int64) string {
+	for _, u := range []struct {
+		suffix string
+		shift  int
+	}{{"G", 30}, {"M", 20}, {"K", 10}} {
+		if n >= 1<<u.shift && n%(1<<u.shift) == 0 {
+			return strconv.FormatInt(n>>u.shift, 10) + u.suffix
+		}
+	}
+	return strconv.FormatInt(n, 10)
+}
+
+// ParseList parses a comma or space separated list of cache sizes, the levels are numbered from 1,
+// e.g. "48K,2M,105M". It overrides the detection on systems without sysfs.
+func ParseList(s string) ([]Cache, error) {
+	var caches []Cache
+	for _, f := range strings.FieldsFunc(s, func(r rune) bool { return r == ',' || r == ' ' }) {
+		size, err := ParseSize(f)
+		if err != nil {
+			return nil, err
+		}
+		caches = append(caches, Cache{Level: len(caches) + 1, Type: "Unified", Size: size})
+	}
+	if len(caches) == 0 {
+		return nil, fmt.Errorf("no cache sizes in %q", s)
+	}
+	return caches, nil
+}
+
+// LLC returns the last level cache, the largest of the caches.
+func LLC(caches []Cache) (Cache, bool) {
+	if len(caches) == 0 {
+		return Cache{}, false
+	}
+	return caches[len(caches)-1], true
+}
@@ -0,0 +1,78 @@
+package cpucache
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func writeIndex(t *testing.T, dir string, files map[string]string) {
+	t.Helper()
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content+"\n"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestRead(t *testing.T) {
+	dir := t.TempDir()
+	writeIndex(t, filepath.Join(dir, "index0"), map[string]string{"level": "1", "type": "Data", "size": "48K", "coherency_line_size": "64"})
+	writeIndex(t, filepath.Join(dir, "index1"), map[string]string{"level": "1", "type": "Instruction", "size": "32K", "coherency_line_size": "64"})
+	writeIndex(t, filepath.Join(dir, "index3"), map[string]string{"level": "3", "type": "Unified", "size": "107520K"})
+	writeIndex(t, filepath.Join(dir, "index2"), map[string]string{"level": "2", "type": "Unified", "size": "2048K", "coherency_line_size": "64"})
+
+	caches, err := Read(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []Cache{
+		{Level: 1, Type: "Data", Size: 48 << 10, LineSize: 64},
+		{Level: 2, Type: "Unified", Size: 2 << 20, LineSize: 64},
+		{Level: 3, Type: "Unified", Size: 105 << 20},
+	}
+	if !reflect.DeepEqual(caches, want) {
+		t.Errorf("Read = %+v, want %+v", caches, want)
+	}
+	if llc, _ := LLC(caches); llc.String() != "L3 105M" {
+		t.Errorf("LLC = %s, want L3 105M", llc)
+	}
+	if _, err := Read(t.TempDir()); err == nil {
+		t.Error("Read of an empty directory succeeded")
+	}
+}
+
+func TestParseSize(t *testing.T) {
+	tests := []struct {
+		in   string
+		want int64
+	}{
+		{"48K", 48 << 10},
+		{"2M", 2 << 20},
+		{"1G", 1 << 30},
+		{"512", 512},
+		{"32KB", 32 << 10},
+	}
+	for _, tt := range tests {
+		got, err := ParseSize(tt.in)
+		if err != nil || got != tt.want {
+			t.Errorf("ParseSize(%q) = %d, %v, want %d", tt.in, got, err, tt.want)
+		}
+		if back, _ := ParseSize(FormatSize(got)); back != got {
+			t.Errorf("FormatSize(%d) = %s does not parse back", got, FormatSize(got))
+		}
+	}
+	for _, in := range []string{"", "K", "-1K", "1T"} {
+		if _, err := ParseSize(in); err == nil {
+			t.Errorf("ParseSize(%q) succeeded", in)
+		}
+	}
+	caches, err := ParseList("48K, 2M,105M")
+	if err != nil || len(caches) != 3 || caches[2].Level != 3 || caches[2].Size != 105<<20 {
+		t.Errorf("ParseList = %+v, %v", caches, err)
+	}
+}
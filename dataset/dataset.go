@@ -0,0 +1,123 @@
+// Package dataset loads key datasets from files, e.g. dumps of real URLs, SKUs or user ids.
+//
+// Supported formats are newline-delimited strings and little-endian binary unsigned integers
+// with 32 or 64 bits.
+package dataset
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Format is the file format of a dataset.
+type Format string
+
+// Supported formats.
+const (
+	Lines Format = "lines"
+	U32   Format = "u32"
+	U64   Format = "u64"
+)
+
+// ParseFormat parses the name of a format. An empty name detects the format from the file extension:
+// .u32 for U32, .u64 and .bin for U64 and Lines otherwise.
+func ParseFormat(name, path string) (Format, error) {
+	switch Format(name) {
+	case Lines, U32, U64:
+		return Format(name), nil
+	case "":
+		switch strings.ToLower(filepath.Ext(path)) {
+		case ".u32":
+			return U32, nil
+		case ".u64", ".bin":
+			return U64, nil
+		}
+		return Lines, nil
+	}
+	return "", fmt.Errorf("unknown dataset format %q, available: lines u32 u64", name)
+}
+
+// Set is a dataset of distinct keys, either strings or integers.
+type Set struct {
+	Path    string
+	Format  Format
+	Strings []string
+	Ints    []uint64
+}
+
+// Load reads the dataset at path. Duplicate keys and empty lines are removed,
+// the order of the first occurrences is kept.
+func Load(path string, format Format) (*Set, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	s := &Set{Path: path, Format: format}
+	switch format {
+	case Lines:
+		seen := make(map[string]bool)
+		sc := bufio.NewScanner(bytes.NewReader(data))
+		sc.Buffer(make([]byte, 64<<10), 1<<20)
+		for sc.Scan() {
+			line := strings.TrimSuffix(sc.Text(), "\r")
+			if line == "" || seen[line] {
+				continue
+			}
+			seen[line] = true
+			s.Strings = append(s.Strings, line)
+		}
+		if err := sc.Err(); err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+	case U32, U64:
+		width := 8
+		if format == U32 {
+			width = 4
+		}
+		if len(data)%width != 0 {
+			return nil, fmt.Errorf("%s: size %d is not a multiple of %d bytes", path, len(data), width)
+		}
+		seen := make(map[uint64]bool, len(data)/width)
+		for i := 0; i < len(data); i += width {
+			var x uint64
+			if width == 4 {
+				x = uint64(binary.LittleEndian.Uint32(data[i:]))
+			} else {
+				x = binary.LittleEndian.Uint64(data[i:])
+			}
+			if seen[x] {
+				continue
+			}
+			seen[x] = true
+			s.Ints = append(s.Ints, x)
+		}
+	default:
+		return nil, fmt.Errorf("unknown dataset format %q", format)
+	}
+	if s.Len() == 0 {
+		return nil, fmt.Errorf("%s: no keys found", path)
+	}
+	return s, nil
+}
+
+// IsInt reports whether the dataset contains integer keys.
+func (s *Set) IsInt() bool {
+	return s.Format != Lines
+}
+
+// Len returns the number of distinct keys.
+func (s *Set) Len() int {
+	return len(s.Strings) + len(s.Ints)
+}
+
+// Shuffle randomizes the order of the keys.
+func (s *Set) Shuffle(rng *rand.Rand) {
+	rng.Shuffle(len(s.Strings), func(i, j int) { s.Strings[i], s.Strings[j] = s.Strings[j], s.Strings[i] })
+	rng.Shuffle(len(s.Ints), func(i, j int) { s.Ints[i], s.Ints[j] = s.Ints[j], s.Ints[i] })
+}
@@ -0,0 +1,53 @@
+package dataset
+
+import (
+	"encoding/binary"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestLoad(t *testing.T) {
+	dir := t.TempDir()
+	lines := filepath.Join(dir, "keys.txt")
+	if err := os.WriteFile(lines, []byte("a\r\nb\n\na\nc"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	ints := filepath.Join(dir, "keys.u32")
+	var data []byte
+	for _, x := range []uint32{7, 1 << 31, 7, 3, 9} {
+		data = binary.LittleEndian.AppendUint32(data, x)
+	}
+	if err := os.WriteFile(ints, data, 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	format, err := ParseFormat("", lines)
+	if err != nil || format != Lines {
+		t.Fatalf("ParseFormat(%q) = %q, %v", lines, format, err)
+	}
+	s, err := Load(lines, format)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"a", "b", "c"}; !reflect.DeepEqual(s.Strings, want) || s.IsInt() {
+		t.Errorf("strings = %q, want %q", s.Strings, want)
+	}
+
+	format, _ = ParseFormat("", ints)
+	s, err = Load(ints, format)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []uint64{7, 1 << 31, 3, 9}; !reflect.DeepEqual(s.Ints, want) || !s.IsInt() {
+		t.Errorf("ints = %v, want %v", s.Ints, want)
+	}
+
+	if _, err := Load(ints, U64); err == nil {
+		t.Error("expected error for a size, which is not a multiple of 8 bytes")
+	}
+	if _, err := ParseFormat("csv", lines); err == nil {
+		t.Error("expected error for unknown format")
+	}
+}
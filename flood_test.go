@@ -0,0 +1,287 @@
+package bench_test
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math/bits"
+	"math/rand"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/EinfachAndy/hashmaps"
+	g "github.com/zyedidia/generic"
+
+	"bench-hashmaps/workload"
+)
+
+// floodGenerators are the adversarial key generators of BenchmarkFlood per key type:
+//   - Random: distinct random keys, the baseline of the slowdown
+//   - Pow2: multiples of 2^16, they share the low bits
+//   - HighBits: keys differing only in the highest bits
+//   - Collide: precomputed collisions of the deterministic hashes, whose low 32 bits are zero,
+//     of g.HashUint64 and the hashmaps package for U64 keys and of the hashmaps package for
+//     U32 and UUID (16 byte strings with identical hashes) keys
+var floodGenerators = map[string][]string{
+	"U32":  {"Random", "Pow2", "HighBits", "Collide"},
+	"U64":  {"Random", "Pow2", "HighBits", "Collide"},
+	"UUID": {"Random", "Collide"},
+}
+
+// floodBreaks are the maps, which do not survive the colliding keys, with the reason. They are not run,
+// but reported with the metric broken.
+var floodBreaks = map[string]string{
+	"robin":            "the probe sequence length is an int8, Put loops forever with more than 127 colliding keys",
+	"robinLowLoad":     "the probe sequence length is an int8, Put loops forever with more than 127 colliding keys",
+	"hopscotch":        "a full neighborhood doubles the table until the memory is exhausted",
+	"hopscotchLowLoad": "a full neighborhood doubles the table until the memory is exhausted",
+}
+
+// floodBaseline contains the ns/key of the Random keys by key type, map and size. It is filled by the Random
+// sub-benchmarks or measured untimed by the other generators, if Random is not selected.
+var floodBaseline = make(map[string]float64)
+
+// untimed is the timer of the baseline passes, which are not part of the measured time.
+type untimed struct{}
+
+func (untimed) StartTimer() {}
+func (untimed) StopTimer()  {}
+
+// BenchmarkFlood measures the Put and Get throughput of keys, which are crafted to collide or cluster
+// in the hash and index scheme of the maps, relative to random keys. The sizes are cfg.FloodSizes,
+// because the colliding keys make the deterministic maps quadratic. The sub-benchmarks are named by
+// key type and generator, e.g. BenchmarkFlood/U64Collide/generic-10000.
+func BenchmarkFlood(b *testing.B) {
+	for _, keyType := range keyTypeNames {
+		for _, gen := range floodGenerators[keyType] {
+			if !cfg.selects(keyType, gen, "Flood") {
+				continue
+			}
+			b.Run(keyType+gen, func(b *testing.B) {
+				for _, n := range cfg.FloodSizes {
+					switch keyType {
+					case "U32":
+						runFlood(b, keyType, gen, genFloodU32(gen, n), func() []uint32 { return genFloodU32("Random", n) })
+					case "U64":
+						runFlood(b, keyType, gen, genFloodU64(gen, n), func() []uint64 { return genFloodU64("Random", n) })
+					case "UUID":
+						runFlood(b, keyType, gen, genFloodStrings(gen, n), func() []string { return genFloodStrings("Random", n) })
+					}
+				}
+			})
+		}
+	}
+}
+
+// runFlood runs the keys against all maps. The x-random metric compares the time per key with the Random keys
+// of the same size, which are generated by random and measured untimed, if the Random baseline is missing.
+func runFlood[K ordered](b *testing.B, keyType, gen string, keys []K, random func() []K) {
+	n := len(keys)
+	for _, mapName := range getMapNames() {
+		b.Run(fmt.Sprintf("%s-%d", mapName, n), func(b *testing.B) {
+			if reason, ok := floodBreaks[mapName]; ok && gen == "Collide" {
+				b.StopTimer()
+				if b.N == 1 {
+					b.Logf("%s is broken: %s", mapName, reason)
+				}
+				b.ReportMetric(1, "broken")
+				return
+			}
+			var put, get time.Duration
+			load := float32(-1.0)
+			b.StopTimer()
+			for i := 0; i < b.N; i++ {
+				pt, gt, l := floodPass(b, mapName, keys, b)
+				put, get, load = put+pt, get+gt, l
+			}
+			report(b, 2*n, load)
+			b.ReportMetric(float64(put.Nanoseconds())/float64(b.N*n), "put-ns/key")
+			b.ReportMetric(float64(get.Nanoseconds())/float64(b.N*n), "get-ns/key")
+
+			nsPerKey := float64((put + get).Nanoseconds()) / float64(b.N*2*n)
+			id := fmt.Sprintf("%s/%s-%d", keyType, mapName, n)
+			if gen == "Random" {
+				floodBaseline[id] = nsPerKey
+				return
+			}
+			if _, ok := floodBaseline[id]; !ok {
+				randomKeys := random()
+				var elapsed time.Duration
+				for i := 0; i < b.N; i++ {
+					pt, gt, _ := floodPass(b, mapName, randomKeys, untimed{})
+					elapsed += pt + gt
+				}
+				floodBaseline[id] = float64(elapsed.Nanoseconds()) / float64(b.N*2*n)
+			}
+			if base := floodBaseline[id]; base > 0 {
+				b.ReportMetric(nsPerKey/base, "x-random")
+			}
+		})
+	}
+}
+
+// floodPass puts and gets the keys in a new map and returns the durations of both and the load factor.
+// Only the operations run within the timer.
+func floodPass[K ordered](b *testing.B, mapName string, keys []K, timer workload.Timer) (put, get time.Duration, load float32) {
+	n := len(keys)
+	m := createMap[K, uint64](0, mapName)
+	runtime.GC()
+	timer.StartTimer()
+	start := time.Now()
+	for _, k := range keys {
+		m.Put(k, uint64(n))
+	}
+	put = time.Since(start)
+	start = time.Now()
+	found := 0
+	for _, k := range keys {
+		if _, ok := m.Get(k); ok {
+			found++
+		}
+	}
+	get = time.Since(start)
+	timer.StopTimer()
+	if found != n {
+		b.Errorf("%s: found %d of %d keys", mapName, found, n)
+	}
+	return put, get, m.Load()
+}
+
+// floodRandom returns n distinct random keys, which are not zero, masked to the given bits.
+func floodRandom(n, width int) []uint64 {
+	values := make(map[uint64]bool, n)
+	values[0] = true
+	arr := make([]uint64, 0, n)
+	for len(arr) < n {
+		x := rand.Uint64() >> (64 - width)
+		if !values[x] {
+			values[x] = true
+			arr = append(arr, x)
+		}
+	}
+	return arr
+}
+
+// floodShift returns the shift of the Pow2 keys, which keeps n keys within width bits.
+func floodShift(n, width int) int {
+	if s := width - bits.Len(uint(n)); s < 16 {
+		return s
+	}
+	return 16
+}
+
+func genFloodU64(gen string, n int) []uint64 {
+	arr := make([]uint64, n)
+	for i := range arr {
+		x := uint64(i + 1)
+		switch gen {
+		case "Pow2":
+			arr[i] = x << floodShift(n, 64)
+		case "HighBits":
+			arr[i] = x << (64 - bits.Len(uint(n)))
+		case "Collide":
+			arr[i] = unhashQword(x << 32)
+		}
+	}
+	if gen == "Random" {
+		return floodRandom(n, 64)
+	}
+	if gen == "Collide" {
+		hasher := hashmaps.GetHasher[uint64]()
+		for _, k := range arr {
+			if uint32(g.HashUint64(k)) != 0 || uint32(hasher(k)) != 0 {
+				panic(fmt.Sprintf("key %x does not collide", k))
+			}
+		}
+	}
+	return arr
+}
+
+func genFloodU32(gen string, n int) []uint32 {
+	arr := make([]uint32, n)
+	shift := 32 - bits.Len(uint(n))
+	for i := range arr {
+		x := uint32(i + 1)
+		switch gen {
+		case "Pow2":
+			arr[i] = x << floodShift(n, 32)
+		case "HighBits":
+			arr[i] = x << shift
+		case "Collide":
+			arr[i] = unhashDword(x << shift)
+		}
+	}
+	if gen == "Random" {
+		for i, x := range floodRandom(n, 32) {
+			arr[i] = uint32(x)
+		}
+	}
+	if gen == "Collide" {
+		hasher := hashmaps.GetHasher[uint32]()
+		for _, k := range arr {
+			if uint32(hasher(k))<<(32-shift) != 0 {
+				panic(fmt.Sprintf("key %x does not collide", k))
+			}
+		}
+	}
+	return arr
+}
+
+// genFloodStrings returns n distinct 16 byte keys. The Collide keys have identical hashes in the hashmaps package.
+func genFloodStrings(gen string, n int) []string {
+	const (
+		offset = 14695981039346656037
+		prime  = 1099511628211
+	)
+	target := rand.Uint64()
+	arr := make([]string, n)
+	var buf [16]byte
+	for i, z1 := range floodRandom(n, 64) {
+		// the modified fnv1a hashes the 2 big-endian words: ((offset ^ z1) * prime ^ z2) * prime
+		z2 := rand.Uint64()
+		if gen == "Collide" {
+			z2 = target*inverse64(prime) ^ (offset^z1)*prime
+		}
+		binary.BigEndian.PutUint64(buf[:8], z1)
+		binary.BigEndian.PutUint64(buf[8:], z2)
+		arr[i] = string(buf[:])
+	}
+	if gen == "Collide" {
+		hasher := hashmaps.GetHasher[string]()
+		for _, k := range arr {
+			if uint64(hasher(k)) != target {
+				panic(fmt.Sprintf("key %x does not collide", k))
+			}
+		}
+	}
+	return arr
+}
+
+// inverse64 returns the multiplicative inverse of the odd x modulo 2^64 by Newton's iteration,
+// each step doubles the number of correct low bits.
+func inverse64(x uint64) uint64 {
+	inv := x
+	for i := 0; i < 5; i++ {
+		inv *= 2 - x*inv
+	}
+	return inv
+}
+
+// unhashQword inverts the MurmurHash3 64 bit finalizer used by g.HashUint64 and the hashmaps package.
+func unhashQword(h uint64) uint64 {
+	// x ^= x >> 33 is its own inverse
+	h ^= h >> 33
+	h *= inverse64(0xc4ceb9fe1a85ec53)
+	h ^= h >> 33
+	h *= inverse64(0xff51afd7ed558ccd)
+	h ^= h >> 33
+	return h
+}
+
+// unhashDword inverts the 32 bit hash of the hashmaps package.
+func unhashDword(h uint32) uint32 {
+	h *= uint32(inverse64(0x1b873593))
+	h = bits.RotateLeft32(h, -15)
+	h *= uint32(inverse64(0xcc9e2d51))
+	return h
+}
@@ -1,6 +1,6 @@
 module bench-hashmaps
 
-go 1.19
+go 1.20
 
 require (
 	github.com/EinfachAndy/hashmaps v0.4.2
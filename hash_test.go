@@ -0,0 +1,72 @@
+package bench_test
+
+import (
+	"fmt"
+	"testing"
+
+	"bench-hashmaps/hashfn"
+	"bench-hashmaps/result"
+)
+
+// hashSink prevents the compiler from removing the hash computations.
+var hashSink uint64
+
+// skipHash skips the hash function benchmarks, unless they are enabled by cfg.HashBench or the scenario
+// is selected, so they do not multiply the duration of the default run.
+func skipHash(b *testing.B) {
+	_, scenario := result.SplitBenchmark(b.Name())
+	if !cfg.HashBench && !contains(cfg.Scenarios, scenario) {
+		b.Skip("the hash function benchmarks are enabled with -hash-bench")
+	}
+}
+
+func benchmarkHash[K comparable](b *testing.B, arr []K, r int) {
+	for _, h := range hashfn.All {
+		hash := hashfn.For[K](h)
+		b.Run(fmt.Sprintf("%s-%d", h.Name, r), func(b *testing.B) {
+			var sum uint64
+			for i := 0; i < b.N; i++ {
+				for j := range arr {
+					sum += hash(arr[j])
+				}
+			}
+			hashSink = sum
+			report(b, r, -1)
+		})
+	}
+}
+
+func BenchmarkU32HashRandom(b *testing.B) {
+	skipHash(b)
+	for _, r := range getRanges() {
+		benchmarkHash(b, genRandIntArray[uint32](r), r)
+	}
+}
+
+func BenchmarkU32HashShuffled(b *testing.B) {
+	skipHash(b)
+	for _, r := range getRanges() {
+		benchmarkHash(b, genShuffledIntArray[uint32](r), r)
+	}
+}
+
+func BenchmarkU64HashRandom(b *testing.B) {
+	skipHash(b)
+	for _, r := range getRanges() {
+		benchmarkHash(b, genRandIntArray[uint64](r), r)
+	}
+}
+
+func BenchmarkU64HashShuffled(b *testing.B) {
+	skipHash(b)
+	for _, r := range getRanges() {
+		benchmarkHash(b, genShuffledIntArray[uint64](r), r)
+	}
+}
+
+func BenchmarkUUIDHash(b *testing.B) {
+	skipHash(b)
+	for _, r := range getRanges() {
+		benchmarkHash(b, genUUIDArray(r), r)
+	}
+}
@@ -0,0 +1,287 @@
+// Package hashfn provides candidate hash functions for integer and string keys, which are compared
+// by standalone throughput benchmarks and plugged into the generic hash map adapter.
+package hashfn
+
+import (
+	"encoding/binary"
+	"hash/maphash"
+	"math/bits"
+	"reflect"
+	"unsafe"
+
+	"github.com/EinfachAndy/hashmaps"
+	g "github.com/zyedidia/generic"
+)
+
+// Hasher is a hash function for the supported key types.
+type Hasher struct {
+	Name   string
+	Uint32 func(uint32) uint64
+	Uint64 func(uint64) uint64
+	String func(string) uint64
+}
+
+// All contains all candidate hash functions.
+var All = []Hasher{
+	{"generic", g.HashUint32, g.HashUint64, g.HashString},
+	{"fnv1a", FNV1aUint32, FNV1aUint64, FNV1aString},
+	{"wyhash", func(x uint32) uint64 { return WyHashUint64(uint64(x)) }, WyHashUint64, WyHashString},
+	{"xxh3", XXH3Uint32, XXH3Uint64, XXH3String},
+	{"maphash", func(x uint32) uint64 { return MapHashUint64(uint64(x)) }, MapHashUint64, MapHashString},
+	{"identity", func(x uint32) uint64 { return uint64(x) }, func(x uint64) uint64 { return x }, IdentityString},
+	{"hashmaps", HashMapsUint32, HashMapsUint64, HashMapsString},
+}
+
+// Names returns the names of all hash functions.
+func Names() []string {
+	names := make([]string, len(All))
+	for i := range All {
+		names[i] = All[i].Name
+	}
+	return names
+}
+
+// Get returns the hash function with the given name.
+func Get(name string) (Hasher, bool) {
+	for _, h := range All {
+		if h.Name == name {
+			return h, true
+		}
+	}
+	return Hasher{}, false
+}
+
+// For returns the hash function of h for the key type K, which must be a 32 or 64 bit
+// unsigned integer or a string. Like the generic adapter, the function is converted with unsafe.
+func For[K comparable](h Hasher) func(K) uint64 {
+	var key K
+	switch reflect.ValueOf(&key).Elem().Type().Kind() {
+	case reflect.Uint32:
+		x := h.Uint32
+		return *(*func(K) uint64)(unsafe.Pointer(&x))
+	case reflect.Uint64:
+		x := h.Uint64
+		return *(*func(K) uint64)(unsafe.Pointer(&x))
+	case reflect.String:
+		x := h.String
+		return *(*func(K) uint64)(unsafe.Pointer(&x))
+	}
+	panic("type not supported")
+}
+
+const (
+	fnvOffset = 14695981039346656037
+	fnvPrime  = 1099511628211
+)
+
+// FNV1aUint32 is the 64 bit FNV-1a hash of the little-endian bytes of x.
+func FNV1aUint32(x uint32) uint64 {
+	h := uint64(fnvOffset)
+	for i := 0; i < 4; i++ {
+		h ^= uint64(byte(x >> (8 * i)))
+		h *= fnvPrime
+	}
+	return h
+}
+
+// FNV1aUint64 is the 64 bit FNV-1a hash of the little-endian bytes of x.
+func FNV1aUint64(x uint64) uint64 {
+	h := uint64(fnvOffset)
+	for i := 0; i < 8; i++ {
+		h ^= uint64(byte(x >> (8 * i)))
+		h *= fnvPrime
+	}
+	return h
+}
+
+// FNV1aString is the 64 bit FNV-1a hash of s.
+func FNV1aString(s string) uint64 {
+	h := uint64(fnvOffset)
+	for i := 0; i < len(s); i++ {
+		h ^= uint64(s[i])
+		h *= fnvPrime
+	}
+	return h
+}
+
+const (
+	wyp0 = 0xa0761d6478bd642f
+	wyp1 = 0xe7037ed1a0b428db
+	wyp2 = 0x8ebc6af09c88c6e3
+	wyp3 = 0x589965cc75374cc3
+)
+
+func wymix(a, b uint64) uint64 {
+	hi, lo := bits.Mul64(a, b)
+	return hi ^ lo
+}
+
+// WyHashUint64 is the wyhash64 mixer of x with a zero seed.
+func WyHashUint64(x uint64) uint64 {
+	a := x ^ 0x2d358dccaa6c78a5
+	b := uint64(0x8bb84b93962eacc9)
+	hi, lo := bits.Mul64(a, b)
+	return wymix(lo^0x2d358dccaa6c78a5, hi^0x8bb84b93962eacc9)
+}
+
+func r4(s string, i int) uint64 {
+	return uint64(s[i]) | uint64(s[i+1])<<8 | uint64(s[i+2])<<16 | uint64(s[i+3])<<24
+}
+
+func r8(s string, i int) uint64 {
+	return r4(s, i) | r4(s, i+4)<<32
+}
+
+// WyHashString is the final version 4 of wyhash of s with a zero seed.
+func WyHashString(s string) uint64 {
+	seed := uint64(0)
+	seed ^= wymix(seed^wyp0, wyp1)
+	n := len(s)
+	var a, b uint64
+	switch {
+	case n <= 16:
+		if n >= 4 {
+			a = r4(s, 0)<<32 | r4(s, (n>>3)<<2)
+			b = r4(s, n-4)<<32 | r4(s, n-4-((n>>3)<<2))
+		} else if n > 0 {
+			a = uint64(s[0])<<16 | uint64(s[n>>1])<<8 | uint64(s[n-1])
+		}
+	default:
+		p, i := 0, n
+		if i > 48 {
+			see1, see2 := seed, seed
+			for i > 48 {
+				seed = wymix(r8(s, p)^wyp1, r8(s, p+8)^seed)
+				see1 = wymix(r8(s, p+16)^wyp2, r8(s, p+24)^see1)
+				see2 = wymix(r8(s, p+32)^wyp3, r8(s, p+40)^see2)
+				p += 48
+				i -= 48
+			}
+			seed ^= see1 ^ see2
+		}
+		for i > 16 {
+			seed = wymix(r8(s, p)^wyp1, r8(s, p+8)^seed)
+			p += 16
+			i -= 16
+		}
+		a = r8(s, p+i-16)
+		b = r8(s, p+i-8)
+	}
+	a ^= wyp1
+	b ^= seed
+	hi, lo := bits.Mul64(a, b)
+	return wymix(lo^wyp0^uint64(n), hi^wyp1)
+}
+
+// xxh3 constants, the bitflip is derived from the default secret
+const (
+	xxhPrime64_1 = 0x9E3779B185EBCA87
+	xxhPrime64_2 = 0xC2B2AE3D27D4EB4F
+	xxhBitflip   = 0x1cad21f72c81017c ^ 0xdb979083e96dd4de
+	xxhRrmxmx    = 0x9FB21C651E98DF25
+)
+
+// rrmxmx is the strong avalanche of xxh3 for inputs of 4 to 8 bytes.
+func rrmxmx(h uint64, n uint64) uint64 {
+	h ^= bits.RotateLeft64(h, 49) ^ bits.RotateLeft64(h, 24)
+	h *= xxhRrmxmx
+	h ^= (h >> 35) + n
+	h *= xxhRrmxmx
+	return h ^ (h >> 28)
+}
+
+// xxhAvalanche is the final mixer of xxh3.
+func xxhAvalanche(h uint64) uint64 {
+	h ^= h >> 37
+	h *= 0x165667919E3779F9
+	return h ^ (h >> 32)
+}
+
+// XXH3Uint32 is the xxh3 hash of the 4 little-endian bytes of x with a zero seed.
+func XXH3Uint32(x uint32) uint64 {
+	input64 := uint64(x) + uint64(x)<<32
+	return rrmxmx(input64^xxhBitflip, 4)
+}
+
+// XXH3Uint64 is the xxh3 hash of the 8 little-endian bytes of x with a zero seed.
+func XXH3Uint64(x uint64) uint64 {
+	input64 := x>>32 + x<<32
+	return rrmxmx(input64^xxhBitflip, 8)
+}
+
+// XXH3String is an xxh3-style hash of s: strings up to 16 bytes follow the short input paths of xxh3,
+// longer strings are mixed in 16 byte stripes by the 128 bit multiply-fold of xxh3.
+// It is not bit compatible with xxh3.
+func XXH3String(s string) uint64 {
+	n := len(s)
+	switch {
+	case n >= 4 && n <= 8:
+		input64 := r4(s, n-4) + r4(s, 0)<<32
+		return rrmxmx(input64^xxhBitflip, uint64(n))
+	case n > 0 && n < 4:
+		c := uint64(s[0])<<16 | uint64(s[n>>1])<<24 | uint64(s[n-1]) | uint64(n)<<8
+		return xxhAvalanche(c ^ 0x87275a9b)
+	case n == 0:
+		return xxhAvalanche(0x8e2a6b1c4b26e7f4)
+	case n <= 16:
+		lo := r8(s, 0) ^ (0x6782737bea4239b9 ^ 0xaff5a3d8a7b9e5f8)
+		hi := r8(s, n-8) ^ (0xa8d59a6c8e91a5b3 ^ 0x1d4cbef5c64e7f6a)
+		mhi, mlo := bits.Mul64(lo, hi)
+		acc := uint64(n) + bits.ReverseBytes64(lo) + hi + (mhi ^ mlo)
+		return xxhAvalanche(acc)
+	}
+	acc := uint64(n) * xxhPrime64_1
+	i := 0
+	for ; i+16 <= n; i += 16 {
+		hi, lo := bits.Mul64(r8(s, i)^0xbe4ba423396cfeb8, r8(s, i+8)^0x1cad21f72c81017c)
+		acc += hi ^ lo
+	}
+	if i < n {
+		hi, lo := bits.Mul64(r8(s, n-16)^0xdb979083e96dd4de, r8(s, n-8)^0x78e5c0cc4ee679cb)
+		acc += hi ^ lo
+	}
+	return xxhAvalanche(acc ^ xxhPrime64_2)
+}
+
+// mapSeed is the fixed seed of the maphash functions within a process.
+var mapSeed = maphash.MakeSeed()
+
+// MapHashUint64 is the runtime hash of the 8 little-endian bytes of x provided by hash/maphash.
+func MapHashUint64(x uint64) uint64 {
+	var b [8]byte
+	binary.LittleEndian.PutUint64(b[:], x)
+	return maphash.Bytes(mapSeed, b[:])
+}
+
+// MapHashString is the runtime hash of s provided by hash/maphash.
+func MapHashString(s string) uint64 {
+	return maphash.String(mapSeed, s)
+}
+
+// IdentityString returns up to the first 8 bytes of s as little-endian integer,
+// the equivalent of the identity hash of integers.
+func IdentityString(s string) uint64 {
+	var h uint64
+	for i := 0; i < len(s) && i < 8; i++ {
+		h |= uint64(s[i]) << (8 * i)
+	}
+	return h
+}
+
+var (
+	hashmapsUint32 = hashmaps.GetHasher[uint32]()
+	hashmapsUint64 = hashmaps.GetHasher[uint64]()
+	hashmapsString = hashmaps.GetHasher[string]()
+)
+
+// HashMapsUint32 is the default hash of the maps of github.com/EinfachAndy/hashmaps for 32 bit keys,
+// the key mixing of murmur3 with a 32 bit result.
+func HashMapsUint32(x uint32) uint64 { return uint64(hashmapsUint32(x)) }
+
+// HashMapsUint64 is the default hash of the maps of github.com/EinfachAndy/hashmaps for 64 bit keys,
+// the 64 bit finalizer of murmur3.
+func HashMapsUint64(x uint64) uint64 { return uint64(hashmapsUint64(x)) }
+
+// HashMapsString is the default hash of the maps of github.com/EinfachAndy/hashmaps for strings,
+// a modified FNV-1a.
+func HashMapsString(s string) uint64 { return uint64(hashmapsString(s)) }
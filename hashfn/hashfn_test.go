@@ -0,0 +1,87 @@
+package hashfn
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/EinfachAndy/hashmaps"
+)
+
+func TestHashers(t *testing.T) {
+	for _, h := range All {
+		if got, ok := Get(h.Name); !ok || got.Name != h.Name {
+			t.Errorf("Get(%q) failed", h.Name)
+		}
+		if h.Name == "identity" {
+			continue
+		}
+		// all lengths exercise the different input paths, the hashes must be distinct
+		seen := make(map[uint64]int)
+		for n := 0; n <= 100; n++ {
+			s := strings.Repeat("x", n)
+			x := h.String(s)
+			if x != h.String(s) {
+				t.Errorf("%s: hash of %q is not deterministic", h.Name, s)
+			}
+			if prev, found := seen[x]; found {
+				t.Errorf("%s: collision of the lengths %d and %d", h.Name, prev, n)
+			}
+			seen[x] = n
+		}
+		if h.Uint64(1) == h.Uint64(2) || h.Uint32(1) == h.Uint32(2) {
+			t.Errorf("%s: collision of 1 and 2", h.Name)
+		}
+	}
+	if FNV1aString("a") != 0xaf63dc4c8601ec8c {
+		t.Errorf("FNV1aString(a) = %x", FNV1aString("a"))
+	}
+}
+
+func TestFor(t *testing.T) {
+	h, _ := Get("wyhash")
+	if For[uint64](h)(42) != WyHashUint64(42) || For[string](h)("42") != WyHashString("42") {
+		t.Error("For returns the wrong function")
+	}
+	h, _ = Get("hashmaps")
+	if For[uint32](h)(42) != uint64(hashmaps.GetHasher[uint32]()(42)) ||
+		For[uint64](h)(42) != uint64(hashmaps.GetHasher[uint64]()(42)) ||
+		For[string](h)("key 42") != uint64(hashmaps.GetHasher[string]()("key 42")) {
+		t.Error("the hashmaps hash differs from hashmaps.GetHasher")
+	}
+}
+
+func TestAnalyze(t *testing.T) {
+	sequential := make([]uint64, 10000)
+	for i := range sequential {
+		sequential[i] = uint64(i + 1)
+	}
+	identity := func(x uint64) uint64 { return x }
+	q := Analyze(identity, sequential, FlipUint64, 64)
+	if !q.Degrades || q.MissProbeRatio < 10 {
+		t.Errorf("identity of sequential keys does not degrade: %+v", q)
+	}
+	if q.AvalancheMaxBias != 0.5 || !q.WeakAvalanche {
+		t.Errorf("avalanche bias of identity = %g", q.AvalancheMaxBias)
+	}
+
+	q = Analyze(WyHashUint64, sequential, FlipUint64, 64)
+	if q.Degrades || q.WeakAvalanche {
+		t.Errorf("wyhash of sequential keys degrades: %+v", q)
+	}
+	if q.HitProbeRatio > 1.2 || q.MissProbeRatio > 1.2 || q.CollisionRatio > 1.2 {
+		t.Errorf("wyhash differs from a random hash: %+v", q)
+	}
+
+	q = Analyze(HashMapsUint64, sequential, FlipUint64, 64)
+	if q.Degrades || q.WeakAvalanche {
+		t.Errorf("hashmaps hash of sequential keys degrades: %+v", q)
+	}
+
+	q = Analyze(func(x uint64) uint64 { return x << 20 }, sequential, FlipUint64, 64)
+	if !q.Degrades || q.CollisionRatio < 2 {
+		t.Errorf("hash without low bits does not degrade: %+v", q)
+	}
+	if got := FlipString("a", 1); got != "c" {
+		t.Errorf("FlipString = %q", got)
+	}
+}
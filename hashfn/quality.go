@@ -0,0 +1,243 @@
+package hashfn
+
+import (
+	"fmt"
+	"math"
+	"math/bits"
+)
+
+// Thresholds of the quality analysis, above which a hash and key combination degrades open addressing.
+const (
+	// MaxProbeRatio is the allowed ratio of the simulated to the expected linear probing lengths.
+	MaxProbeRatio = 1.5
+	// MaxCollisionRatio is the allowed ratio of the observed to the expected low bit collisions.
+	MaxCollisionRatio = 1.5
+	// MaxChiSquareZ is the allowed deviation from a uniform distribution of the low bits in standard scores.
+	MaxChiSquareZ = 10
+	// MaxAvalancheBias is the bias of the avalanche, above which the hash is reported as weak.
+	MaxAvalancheBias = 0.25
+	// LoadFactor is the load factor of the simulated open addressing table.
+	LoadFactor = 0.8
+	// avalancheKeys is the number of keys used for the avalanche test.
+	avalancheKeys = 1000
+)
+
+// ChiSquare is the chi-square test of the uniformity of the low bits of the hashes.
+type ChiSquare struct {
+	// Bits is the number of low bits, the bucket count is 2^Bits.
+	Bits int     `json:"bits"`
+	Chi2 float64 `json:"chi2"`
+	DF   float64 `json:"df"`
+	// Z is the standard score of the Wilson-Hilferty approximation, values above 3 indicate a non uniform distribution.
+	Z float64 `json:"z"`
+}
+
+// Quality is the result of the quality analysis of a hash function for a set of keys.
+type Quality struct {
+	Hash      string `json:"hash"`
+	KeyType   string `json:"keyType"`
+	Generator string `json:"generator"`
+	Keys      int    `json:"keys"`
+	// AvalancheMaxBias and AvalancheMeanBias are the maximal and mean deviation of the probability,
+	// that an output bit flips if a single input bit flips, from the ideal 0.5.
+	AvalancheMaxBias  float64     `json:"avalancheMaxBias"`
+	AvalancheMeanBias float64     `json:"avalancheMeanBias"`
+	ChiSquares        []ChiSquare `json:"chiSquares"`
+	// TableBits is the size of the simulated table, 2^TableBits slots with at most LoadFactor.
+	TableBits int `json:"tableBits"`
+	// CollisionRatio is the ratio of the keys, whose low bits collide with a previous key, to the
+	// expected ratio of a random hash.
+	CollisionRatio float64 `json:"collisionRatio"`
+	// HitProbes and MissProbes are the mean probe lengths of successful and unsuccessful lookups
+	// in the simulated linear probing table, the ratios are relative to the expected lengths of a random hash.
+	HitProbes       float64  `json:"hitProbes"`
+	HitProbeRatio   float64  `json:"hitProbeRatio"`
+	MissProbes      float64  `json:"missProbes"`
+	MissProbeRatio  float64  `json:"missProbeRatio"`
+	WeakAvalanche   bool     `json:"weakAvalanche"`
+	Degrades        bool     `json:"degrades"`
+	Reasons         []string `json:"reasons,omitempty"`
+	WorstChiSquareZ float64  `json:"worstChiSquareZ"`
+}
+
+// FlipUint32 flips the bit of x.
+func FlipUint32(x uint32, bit int) uint32 {
+	return x ^ 1<<bit
+}
+
+// FlipUint64 flips the bit of x.
+func FlipUint64(x uint64, bit int) uint64 {
+	return x ^ 1<<bit
+}
+
+// FlipString flips the bit of s, bit 0 is the lowest bit of the first byte.
+func FlipString(s string, bit int) string {
+	b := []byte(s)
+	b[bit/8] ^= 1 << (bit % 8)
+	return string(b)
+}
+
+// Analyze measures the quality of the hash function h for the keys. The avalanche is tested with
+// the first input bits of the keys, which are flipped by flip.
+func Analyze[K comparable](h func(K) uint64, keys []K, flip func(K, int) K, inputBits int) Quality {
+	q := Quality{Keys: len(keys)}
+	if len(keys) == 0 {
+		return q
+	}
+	hashes := make([]uint64, len(keys))
+	for i, k := range keys {
+		hashes[i] = h(k)
+	}
+
+	q.AvalancheMaxBias, q.AvalancheMeanBias = avalanche(h, keys, flip, inputBits)
+	q.ChiSquares = chiSquares(hashes)
+	q.TableBits = bits.Len(uint(math.Ceil(float64(len(keys))/LoadFactor)) - 1)
+	q.CollisionRatio = collisionRatio(hashes, q.TableBits)
+	q.HitProbes, q.MissProbes = linearProbing(hashes, q.TableBits)
+	alpha := float64(len(keys)) / float64(uint64(1)<<q.TableBits)
+	q.HitProbeRatio = q.HitProbes / (0.5 * (1 + 1/(1-alpha)))
+	q.MissProbeRatio = q.MissProbes / (0.5 * (1 + 1/((1-alpha)*(1-alpha))))
+
+	for _, c := range q.ChiSquares {
+		if c.Z > q.WorstChiSquareZ {
+			q.WorstChiSquareZ = c.Z
+		}
+	}
+	if q.AvalancheMaxBias > MaxAvalancheBias {
+		q.WeakAvalanche = true
+	}
+	if q.MissProbeRatio > MaxProbeRatio {
+		q.Reasons = append(q.Reasons, fmt.Sprintf("misses probe %.1fx longer than with a random hash (clustering)", q.MissProbeRatio))
+	}
+	if q.HitProbeRatio > MaxProbeRatio {
+		q.Reasons = append(q.Reasons, fmt.Sprintf("hits probe %.1fx longer than with a random hash", q.HitProbeRatio))
+	}
+	if q.CollisionRatio > MaxCollisionRatio {
+		q.Reasons = append(q.Reasons, fmt.Sprintf("%.1fx more low bit collisions than with a random hash", q.CollisionRatio))
+	}
+	if q.WorstChiSquareZ > MaxChiSquareZ {
+		q.Reasons = append(q.Reasons, fmt.Sprintf("low bits are not uniformly distributed (chi-square z=%.0f)", q.WorstChiSquareZ))
+	}
+	q.Degrades = len(q.Reasons) > 0
+	return q
+}
+
+// avalanche returns the maximal and mean bias of the output bit flips.
+func avalanche[K comparable](h func(K) uint64, keys []K, flip func(K, int) K, inputBits int) (float64, float64) {
+	if len(keys) > avalancheKeys {
+		keys = keys[:avalancheKeys]
+	}
+	if inputBits <= 0 {
+		return 0, 0
+	}
+	flips := make([][64]int, inputBits)
+	for _, k := range keys {
+		x := h(k)
+		for i := 0; i < inputBits; i++ {
+			diff := x ^ h(flip(k, i))
+			for j := 0; j < 64; j++ {
+				flips[i][j] += int(diff >> j & 1)
+			}
+		}
+	}
+	maxBias, sum := 0.0, 0.0
+	for i := range flips {
+		for j := range flips[i] {
+			bias := math.Abs(float64(flips[i][j])/float64(len(keys)) - 0.5)
+			sum += bias
+			if bias > maxBias {
+				maxBias = bias
+			}
+		}
+	}
+	return maxBias, sum / float64(inputBits*64)
+}
+
+// chiSquares tests the uniformity of the low bits for all power of two bucket counts,
+// which have at least 5 expected keys per bucket.
+func chiSquares(hashes []uint64) []ChiSquare {
+	var result []ChiSquare
+	for b := 1; b <= 24 && float64(len(hashes))/float64(uint64(1)<<b) >= 5; b++ {
+		buckets := make([]int, 1<<b)
+		mask := uint64(1)<<b - 1
+		for _, h := range hashes {
+			buckets[h&mask]++
+		}
+		expected := float64(len(hashes)) / float64(len(buckets))
+		chi2 := 0.0
+		for _, c := range buckets {
+			d := float64(c) - expected
+			chi2 += d * d / expected
+		}
+		df := float64(len(buckets) - 1)
+		// Wilson-Hilferty transformation of the chi-square distribution into a standard normal one
+		v := 2 / (9 * df)
+		z := (math.Cbrt(chi2/df) - (1 - v)) / math.Sqrt(v)
+		result = append(result, ChiSquare{Bits: b, Chi2: chi2, DF: df, Z: z})
+	}
+	return result
+}
+
+// collisionRatio returns the ratio of the observed to the expected collisions in the low tableBits.
+func collisionRatio(hashes []uint64, tableBits int) float64 {
+	m := uint64(1) << tableBits
+	seen := make([]bool, m)
+	collisions := 0
+	for _, h := range hashes {
+		i := h & (m - 1)
+		if seen[i] {
+			collisions++
+		}
+		seen[i] = true
+	}
+	n := float64(len(hashes))
+	// n keys in m buckets occupy m*(1-(1-1/m)^n) buckets on average
+	expected := n - float64(m)*(1-math.Pow(1-1/float64(m), n))
+	if expected <= 0 {
+		return 0
+	}
+	return float64(collisions) / expected
+}
+
+// linearProbing inserts the hashes into a linear probing table with 2^tableBits slots and returns
+// the mean probe lengths of successful lookups and of unsuccessful lookups starting at any slot.
+func linearProbing(hashes []uint64, tableBits int) (float64, float64) {
+	m := 1 << tableBits
+	mask := m - 1
+	occupied := make([]bool, m)
+	hit := 0
+	for _, h := range hashes {
+		i := int(h) & mask
+		probes := 1
+		for occupied[i] {
+			i = (i + 1) & mask
+			probes++
+		}
+		occupied[i] = true
+		hit += probes
+	}
+
+	// the probe length of a miss is the distance to the next empty slot plus one
+	empty := -1
+	for i := m - 1; i >= 0 && empty < 0; i-- {
+		if !occupied[i] {
+			empty = i
+		}
+	}
+	if empty < 0 {
+		return float64(hit) / float64(len(hashes)), math.Inf(1)
+	}
+	miss := 0
+	dist := 0
+	for k := 0; k < m; k++ {
+		// walk backwards starting at the last empty slot, so that the next empty slot is known
+		i := (empty - k + m) & mask
+		if occupied[i] {
+			dist++
+		} else {
+			dist = 0
+		}
+		miss += dist + 1
+	}
+	return float64(hit) / float64(len(hashes)), float64(miss) / float64(m)
+}
@@ -0,0 +1,85 @@
+package bench_test
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+	"time"
+
+	"bench-hashmaps/result"
+)
+
+// TestMain sets up the benchmark configuration from the environment variables, an optional
+// config file and flags. With the json format, the benchmark results are additionally written
+// as JSON document.
+func TestMain(m *testing.M) {
+	flag.Parse()
+	if err := setupConfig(); err != nil {
+		fmt.Fprintln(os.Stderr, "invalid benchmark configuration:", err)
+		os.Exit(2)
+	}
+	rand.Seed(*cfg.Seed)
+
+	start := time.Now()
+	path := cfg.jsonPath(start)
+	if path == "" {
+		os.Exit(m.Run())
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		fmt.Fprintln(os.Stderr, "creating output directory failed:", err)
+		os.Exit(1)
+	}
+
+	stdout := os.Stdout
+	r, w, err := os.Pipe()
+	if err != nil {
+		panic(err)
+	}
+	os.Stdout = w
+
+	type readResult struct {
+		run *result.Run
+		err error
+	}
+	done := make(chan readResult)
+	go func() {
+		tee := io.TeeReader(r, stdout)
+		run, err := result.ReadLenient(tee, func(err error) {
+			fmt.Fprintln(os.Stderr, "skipping unparsable benchmark output:", err)
+		})
+		// forward the remaining output, even if it could not be parsed
+		_, _ = io.Copy(io.Discard, tee)
+		done <- readResult{run, err}
+	}()
+
+	code := m.Run()
+	end := time.Now()
+
+	w.Close()
+	os.Stdout = stdout
+	res := <-done
+	if res.err != nil {
+		// the records before the error are still written
+		fmt.Fprintln(os.Stderr, "parsing benchmark output failed:", res.err)
+	}
+
+	res.run.Metadata.Hostname, _ = os.Hostname()
+	res.run.Metadata.GoVersion = runtime.Version()
+	res.run.Metadata.GoMaxProcs = runtime.GOMAXPROCS(0)
+	res.run.Metadata.Seed = *cfg.Seed
+	res.run.Metadata.Dataset = cfg.Dataset
+	res.run.Metadata.KeyPattern = cfg.KeyPattern
+	res.run.Metadata.Caches = caches
+	res.run.Metadata.Start = start.Format(time.RFC3339)
+	res.run.Metadata.End = end.Format(time.RFC3339)
+	if err := res.run.WriteJSONFile(path); err != nil {
+		fmt.Fprintln(os.Stderr, "writing json output failed:", err)
+		os.Exit(1)
+	}
+	os.Exit(code)
+}
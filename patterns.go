@@ -0,0 +1,125 @@
+package bench_test
+
+import (
+	"fmt"
+	"math/rand"
+	"unsafe"
+
+	"golang.org/x/exp/constraints"
+)
+
+// keyPatterns are the integer key patterns of the configuration. Except random, they replace the
+// dense and random keys of all U32 and U64 scenarios:
+//   - strided: multiples of 4096, e.g. page aligned addresses
+//   - timestamp: monotone increasing timestamps with small random gaps
+//   - snowflake: 64 bit ids of 41 bits milliseconds, 10 bits machine and 12 bits sequence,
+//     32 bit ids of 22 bits time, 4 bits machine and 6 bits sequence
+//   - clustered: ranges of 1024 consecutive ids separated by random gaps
+//   - low16: keys with only the low 16 bits random, the higher bits count up. The 32 bit keys have only
+//     16 counting bits, so they wrap after 65536 keys and larger U32 sizes are rejected by validate.
+var keyPatterns = []string{"random", "strided", "timestamp", "snowflake", "clustered", "low16"}
+
+const (
+	patternStride  = 4096
+	patternCluster = 1024
+)
+
+// usePattern reports whether the configured key pattern replaces the generated integer keys.
+func usePattern() bool {
+	return cfg.KeyPattern != "" && cfg.KeyPattern != "random"
+}
+
+// genPatternInts returns n distinct keys of the configured pattern, which are not contained in exclude.
+// Like the generators, the key 0 is never used. The keys are truncated to the width of V.
+func genPatternInts[V constraints.Integer](n int, exclude []V) []V {
+	var zero V
+	width := int(unsafe.Sizeof(zero)) * 8
+	var arr []V
+	// the random patterns produce partly the same keys again, so more candidates may be required. A larger
+	// candidate set replaces the previous one instead of extending it, which would break the order of the
+	// timestamps and snowflake ids.
+	for size := n + len(exclude); size <= 8*(n+len(exclude)); size *= 2 {
+		values := make(map[V]bool, n+len(exclude))
+		values[0] = true
+		for _, x := range exclude {
+			values[x] = true
+		}
+		arr = make([]V, 0, n)
+		for _, x := range patternKeys(cfg.KeyPattern, size, width) {
+			if !values[V(x)] {
+				values[V(x)] = true
+				arr = append(arr, V(x))
+				if len(arr) == n {
+					return arr
+				}
+			}
+		}
+	}
+	panic(fmt.Sprintf("key pattern %s yields only %d distinct keys of type %T, but %d are required",
+		cfg.KeyPattern, len(arr), zero, n))
+}
+
+// patternKeys returns n candidate keys of the pattern for keys of the given width in bits. The timestamps
+// and snowflake ids are in the order of their creation, the other patterns are shuffled.
+func patternKeys(pattern string, n, width int) []uint64 {
+	arr := make([]uint64, n)
+	switch pattern {
+	case "strided":
+		for i := range arr {
+			arr[i] = uint64(i+1) * patternStride
+		}
+	case "timestamp":
+		// seconds for 32 bit keys, nanoseconds since 2023 for 64 bit keys
+		t, gap := uint64(1_700_000_000), 64
+		if width == 64 {
+			t, gap = 1_700_000_000_000_000_000+uint64(rand.Int63n(1e15)), 2000
+		}
+		for i := range arr {
+			t += uint64(1 + rand.Intn(gap))
+			arr[i] = t
+		}
+	case "snowflake":
+		ms, machineBits, seqBits := uint64(1<<40+rand.Int63n(1<<30)), 10, 12
+		if width == 32 {
+			ms, machineBits, seqBits = uint64(rand.Intn(1<<20)), 4, 6
+		}
+		machines := make([]uint64, 16)
+		for i := range machines {
+			machines[i] = uint64(rand.Intn(1 << machineBits))
+		}
+		seq := make([]uint64, len(machines))
+		for i := range arr {
+			m := rand.Intn(len(machines))
+			if rand.Intn(64) == 0 || seq[m] == 1<<seqBits {
+				ms++
+				for j := range seq {
+					seq[j] = 0
+				}
+			}
+			arr[i] = ms<<(machineBits+seqBits) | machines[m]<<seqBits | seq[m]
+			seq[m]++
+		}
+	case "clustered":
+		base := uint64(1 + rand.Intn(1<<20))
+		for i := range arr {
+			if i > 0 && i%patternCluster == 0 {
+				base += patternCluster + uint64(1+rand.Intn(1<<20))
+			}
+			arr[i] = base + uint64(i%patternCluster)
+		}
+	case "low16":
+		var prefix uint64
+		if width == 64 {
+			prefix = rand.Uint64() &^ (1<<48 - 1)
+		}
+		for i := range arr {
+			arr[i] = prefix + uint64(i)<<16 | uint64(rand.Intn(1<<16))
+		}
+	default:
+		panic("unknown key pattern: " + pattern)
+	}
+	if pattern != "timestamp" && pattern != "snowflake" {
+		rand.Shuffle(len(arr), func(i, j int) { arr[i], arr[j] = arr[j], arr[i] })
+	}
+	return arr
+}
@@ -0,0 +1,120 @@
+package bench_test
+
+import (
+	"fmt"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/EinfachAndy/hashmaps"
+)
+
+// BenchmarkPopulation holds M maps of k entries each, like entity models with a map per object, and
+// measures the time to build and scan them, the heap per map and the impact on the garbage collector.
+// M are cfg.PopulationMaps and k are cfg.PopulationEntries, the sub-benchmarks are named by key type
+// and k, e.g. BenchmarkPopulation/U64Population8/swiss-1000000 for 1,000,000 maps of 8 entries.
+func BenchmarkPopulation(b *testing.B) {
+	for _, keyType := range []string{"U64", "UUID"} {
+		if !cfg.selects(keyType, "Population") {
+			continue
+		}
+		for _, k := range cfg.PopulationEntries {
+			b.Run(fmt.Sprintf("%sPopulation%d", keyType, k), func(b *testing.B) {
+				for _, count := range cfg.PopulationMaps {
+					switch keyType {
+					case "U64":
+						runPopulation(b, count, k, genPopulationPool(count, k, genRandIntArray[uint64]))
+					case "UUID":
+						runPopulation(b, count, k, genPopulationPool(count, k, genUUIDArray))
+					}
+				}
+			})
+		}
+	}
+}
+
+// genPopulationPool returns the distinct keys shared by count maps of k entries.
+func genPopulationPool[K any](count, k int, gen func(int) []K) []K {
+	n := count * k
+	if n > smallPool {
+		n = smallPool
+	}
+	if n < k {
+		n = k
+	}
+	return gen(n)
+}
+
+// runPopulation reports besides the default metrics:
+//   - build-ns/map and scan-ns/map: the creation and lookup of all keys of a map
+//   - B/map, allocs/map and heap-MB: the retained heap including the createMap adapter and the allocations
+//   - build-gcs and build-pause-us: the garbage collections and their stop the world pauses during the build
+//   - gc-ms and gc-pause-us: the duration and pause of a full collection with all maps alive
+func runPopulation[K ordered](b *testing.B, count, k int, pool []K) {
+	for _, mapName := range getMapNames() {
+		b.Run(fmt.Sprintf("%s-%d", mapName, count), func(b *testing.B) {
+			var build, scan, gc time.Duration
+			var allocs, buildGCs, buildPause, gcPause uint64
+			var heap int64
+			var mem runtime.MemStats
+			load := float32(-1.0)
+			for i := 0; i < b.N; i++ {
+				b.StopTimer()
+				runtime.GC()
+				runtime.ReadMemStats(&mem)
+				baseHeap, baseMallocs, baseGCs, basePause := int64(mem.HeapAlloc), mem.Mallocs, mem.NumGC, mem.PauseTotalNs
+				b.StartTimer()
+
+				start := time.Now()
+				maps := make([]hashmaps.IHashMap[K, uint64], count)
+				for j := range maps {
+					maps[j] = createMap[K, uint64](0, mapName)
+					for _, key := range smallKeys(pool, j, k) {
+						maps[j].Put(key, 1)
+					}
+				}
+				build += time.Since(start)
+
+				b.StopTimer()
+				runtime.ReadMemStats(&mem)
+				allocs += mem.Mallocs - baseMallocs
+				buildGCs += uint64(mem.NumGC - baseGCs)
+				buildPause += mem.PauseTotalNs - basePause
+				start = time.Now()
+				runtime.GC()
+				gc += time.Since(start)
+				runtime.ReadMemStats(&mem)
+				gcPause += mem.PauseNs[(mem.NumGC+255)%256]
+				// like tableBytes, the heap may shrink below the base, if garbage of earlier runs was collected
+				heap += int64(mem.HeapAlloc) - baseHeap
+				b.StartTimer()
+
+				start = time.Now()
+				for j := range maps {
+					for _, key := range smallKeys(pool, j, k) {
+						if _, found := maps[j].Get(key); !found {
+							b.Fatal("inserted key not found")
+						}
+					}
+				}
+				scan += time.Since(start)
+				b.StopTimer()
+
+				load = maps[0].Load()
+				runtime.KeepAlive(maps)
+			}
+			report(b, count*k, load)
+			runs := float64(b.N)
+			perMap := runs * float64(count)
+			b.ReportMetric(float64(build.Nanoseconds())/perMap, "build-ns/map")
+			b.ReportMetric(float64(scan.Nanoseconds())/perMap, "scan-ns/map")
+			b.ReportMetric(float64(heap)/perMap, "B/map")
+			b.ReportMetric(float64(heap)/runs/(1<<20), "heap-MB")
+			b.ReportMetric(float64(allocs)/perMap, "allocs/map")
+			b.ReportMetric(float64(buildGCs)/runs, "build-gcs")
+			b.ReportMetric(float64(buildPause)/runs/1e3, "build-pause-us")
+			b.ReportMetric(float64(gc.Nanoseconds())/runs/1e6, "gc-ms")
+			b.ReportMetric(float64(gcPause)/runs/1e3, "gc-pause-us")
+		})
+	}
+}
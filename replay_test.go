@@ -0,0 +1,90 @@
+package bench_test
+
+import (
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"strings"
+	"testing"
+
+	"bench-hashmaps/trace"
+)
+
+var traceNameReplacer = regexp.MustCompile(`[^A-Za-z0-9_]+`)
+
+// BenchmarkTrace replays the recorded traces of the configuration against all maps, see package trace.
+// The sub-benchmarks are named by the trace file and the number of operations, e.g. BenchmarkTrace/service/std-1000000.
+// Besides the metrics of report, the mean and 99th percentile latency of each operation are reported.
+func BenchmarkTrace(b *testing.B) {
+	paths, err := filepath.Glob(cfg.Traces)
+	if err != nil {
+		b.Fatal(err)
+	}
+	if len(paths) == 0 {
+		b.Skipf("no trace files found: %s", cfg.Traces)
+	}
+	for _, path := range paths {
+		name := strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+		name = traceNameReplacer.ReplaceAllString(name, "_")
+		if !cfg.selects("", name, "Trace") {
+			continue
+		}
+		r, err := trace.Open(path)
+		if err != nil {
+			b.Fatal(err)
+		}
+		events, err := r.ReadAll()
+		r.Close()
+		if err != nil {
+			b.Fatalf("%s: %v", path, err)
+		}
+		h := r.Header()
+		b.Run(name, func(b *testing.B) {
+			switch {
+			case h.KeyKind == trace.KindString:
+				runTrace(b, trace.NewReplay(events, trace.StringKey))
+			case h.Encoding == trace.Raw && h.KeySize <= 4:
+				runTrace(b, trace.NewReplay(events, func(e *trace.Event) uint32 { return uint32(e.Int) }))
+			default:
+				runTrace(b, trace.NewReplay(events, trace.IntKey))
+			}
+		})
+	}
+}
+
+func runTrace[K ordered](b *testing.B, replay *trace.Replay[K]) {
+	expected := replay.Profile(createMap[K, uint64](0, "std")).Found
+	n := len(replay.Ops)
+	for _, mapName := range getMapNames() {
+		b.Run(fmt.Sprintf("%s-%d", mapName, n), func(b *testing.B) {
+			load := float32(-1.0)
+			for i := 0; i < b.N; i++ {
+				b.StopTimer()
+
+				m := createMap[K, uint64](0, mapName)
+
+				b.StartTimer()
+				replay.Run(m)
+				b.StopTimer()
+				runtime.GC() // more accurate memory tracking
+
+				load = m.Load()
+			}
+			report(b, n, load)
+
+			// the latencies are measured in a separate pass, which also verifies the results
+			p := replay.Profile(createMap[K, uint64](0, mapName))
+			for i := range expected {
+				if p.Found[i] != expected[i] {
+					b.Errorf("%s: get %d returned found=%v, but std returned %v", mapName, i, p.Found[i], expected[i])
+					break
+				}
+			}
+			for op, s := range p.Stats {
+				b.ReportMetric(float64(s.Mean.Nanoseconds()), "ns/"+op.String())
+				b.ReportMetric(float64(s.P99.Nanoseconds()), "p99-ns/"+op.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,311 @@
+// Renders the benchmark charts as SVG without any external dependency.
+(function () {
+  "use strict";
+
+  const palette = ["#1f77b4", "#ff7f0e", "#2ca02c", "#d62728", "#9467bd", "#8c564b",
+    "#e377c2", "#7f7f7f", "#bcbd22", "#17becf", "#393b79", "#637939", "#8c6d31", "#843c39"];
+  const svgNS = "http://www.w3.org/2000/svg";
+  const margin = {left: 80, right: 20, top: 20, bottom: 50};
+
+  const state = {
+    hidden: new Set(),
+    logX: true,
+    logY: false,
+    metric: "",
+  };
+
+  function color(mapName) {
+    return palette[report.maps.indexOf(mapName) % palette.length];
+  }
+
+  function el(name, attrs, text) {
+    const e = document.createElementNS(svgNS, name);
+    for (const k in attrs) {
+      e.setAttribute(k, attrs[k]);
+    }
+    if (text !== undefined) {
+      e.textContent = text;
+    }
+    return e;
+  }
+
+  function fmt(v) {
+    if (v === 0) {
+      return "0";
+    }
+    const a = Math.abs(v);
+    if (a >= 1e6 || a < 1e-3) {
+      return v.toExponential(1);
+    }
+    return Number(v.toPrecision(3)).toString();
+  }
+
+  function scale(min, max, from, to, log) {
+    if (log) {
+      min = Math.log10(min);
+      max = Math.log10(max);
+    }
+    if (min === max) {
+      min -= 1;
+      max += 1;
+    }
+    const f = function (v) {
+      const x = log ? Math.log10(v) : v;
+      return from + (x - min) / (max - min) * (to - from);
+    };
+    f.ticks = function () {
+      const ticks = [];
+      if (log) {
+        for (let e = Math.floor(min); e <= Math.ceil(max); e++) {
+          for (const m of [1, 2, 5]) {
+            const t = m * Math.pow(10, e);
+            if (Math.log10(t) >= min && Math.log10(t) <= max) {
+              ticks.push(t);
+            }
+          }
+        }
+        return ticks;
+      }
+      const step = Math.pow(10, Math.floor(Math.log10((max - min) / 5)));
+      const mult = [1, 2, 5, 10].find(m => (max - min) / (m * step) <= 8);
+      for (let t = Math.ceil(min / (mult * step)) * mult * step; t <= max; t += mult * step) {
+        ticks.push(t);
+      }
+      return ticks;
+    };
+    return f;
+  }
+
+  function currentView(chart) {
+    return chart.views.find(v => v.metric === state.metric) || chart.views[0];
+  }
+
+  function draw(chart) {
+    const root = document.getElementById(chart.id);
+    const svg = root.querySelector("svg");
+    const toolbar = root.querySelector(".toolbar");
+    while (svg.firstChild) {
+      svg.removeChild(svg.firstChild);
+    }
+    const width = svg.width.baseVal.value;
+    const height = svg.height.baseVal.value;
+    const view = currentView(chart);
+    toolbar.textContent = view.metric === state.metric || state.metric === "" ? "" : "metric " + state.metric + " not available";
+
+    const series = view.series.filter(s => !state.hidden.has(s.map));
+    const points = series.flatMap(s => s.points);
+    if (points.length === 0) {
+      svg.appendChild(el("text", {x: width / 2, y: height / 2, "text-anchor": "middle"}, "no data"));
+      return;
+    }
+    const logY = state.logY && points.every(p => p.y > 0);
+    // the cache sweeps are drawn over the table bytes to compare them with the cache levels
+    const px = chart.tableBytes ? p => p.tableBytes : p => p.x;
+    const xs = points.map(px);
+    const ys = points.map(p => p.y);
+    const x = scale(Math.min(...xs), Math.max(...xs), margin.left, width - margin.right, state.logX && Math.min(...xs) > 0);
+    const y = scale(logY ? Math.min(...ys) : Math.min(0, ...ys), Math.max(...ys), height - margin.bottom, margin.top, logY);
+
+    for (const t of x.ticks()) {
+      svg.appendChild(el("line", {class: "grid", x1: x(t), x2: x(t), y1: margin.top, y2: height - margin.bottom}));
+      svg.appendChild(el("text", {x: x(t), y: height - margin.bottom + 15, "text-anchor": "middle"}, fmt(t)));
+    }
+    for (const t of y.ticks()) {
+      svg.appendChild(el("line", {class: "grid", x1: margin.left, x2: width - margin.right, y1: y(t), y2: y(t)}));
+      svg.appendChild(el("text", {x: margin.left - 6, y: y(t) + 4, "text-anchor": "end"}, fmt(t)));
+    }
+    svg.appendChild(el("line", {class: "axis", x1: margin.left, x2: width - margin.right, y1: height - margin.bottom, y2: height - margin.bottom}));
+    svg.appendChild(el("line", {class: "axis", x1: margin.left, x2: margin.left, y1: margin.top, y2: height - margin.bottom}));
+    svg.appendChild(el("text", {x: (width + margin.left) / 2, y: height - 10, "text-anchor": "middle"},
+      chart.tableBytes ? "size of the hash table (bytes)" : "number of entries in hash table (n)"));
+    if (chart.tableBytes) {
+      for (const c of report.caches || []) {
+        const cx = x(c.size);
+        if (cx < margin.left || cx > width - margin.right) {
+          continue;
+        }
+        svg.appendChild(el("line", {class: "cache", x1: cx, x2: cx, y1: margin.top, y2: height - margin.bottom}));
+        svg.appendChild(el("text", {x: cx + 4, y: margin.top + 12}, "L" + c.level + " " + fmt(c.size) + " B"));
+      }
+    }
+    svg.appendChild(el("text", {x: 15, y: height / 2, "text-anchor": "middle", transform: "rotate(-90 15 " + height / 2 + ")"}, view.label));
+
+    for (const s of series) {
+      const c = color(s.map);
+      svg.appendChild(el("polyline", {
+        points: s.points.map(p => x(px(p)) + "," + y(p.y)).join(" "),
+        fill: "none", stroke: c, "stroke-width": 1.5,
+      }));
+      for (const p of s.points) {
+        if (p.ci > 0) {
+          svg.appendChild(el("line", {x1: x(px(p)), x2: x(px(p)), y1: y(Math.max(p.y - p.ci, logY ? p.y / 10 : -Infinity)), y2: y(p.y + p.ci), stroke: c}));
+        }
+        const dot = el("circle", {cx: x(px(p)), cy: y(p.y), r: 3, fill: c});
+        let title = s.map + " n=" + p.x + ": " + fmt(p.y);
+        if (p.tableBytes > 0) {
+          title += " table=" + fmt(p.tableBytes) + " B";
+        }
+        if (p.ci > 0) {
+          title += " ± " + fmt(p.ci) + " (" + p.n + " runs)";
+        }
+        if (p.load > 0) {
+          title += " load=" + p.load.toFixed(4);
+        }
+        dot.appendChild(el("title", {}, title));
+        svg.appendChild(dot);
+      }
+    }
+  }
+
+  function drawFrontier(svg, f) {
+    while (svg.firstChild) {
+      svg.removeChild(svg.firstChild);
+    }
+    const width = svg.width.baseVal.value;
+    const height = svg.height.baseVal.value;
+    const points = f.points.filter(p => !state.hidden.has(p.map));
+    if (points.length === 0) {
+      svg.appendChild(el("text", {x: width / 2, y: height / 2, "text-anchor": "middle"}, "no data"));
+      return;
+    }
+    const xs = points.map(p => p.bytesPerEntry);
+    const ys = points.map(p => p.time);
+    const x = scale(0, Math.max(...xs) * 1.1, margin.left, width - margin.right, false);
+    const y = scale(0, Math.max(...ys) * 1.1, height - margin.bottom, margin.top, false);
+    for (const t of x.ticks()) {
+      svg.appendChild(el("line", {class: "grid", x1: x(t), x2: x(t), y1: margin.top, y2: height - margin.bottom}));
+      svg.appendChild(el("text", {x: x(t), y: height - margin.bottom + 15, "text-anchor": "middle"}, fmt(t)));
+    }
+    for (const t of y.ticks()) {
+      svg.appendChild(el("line", {class: "grid", x1: margin.left, x2: width - margin.right, y1: y(t), y2: y(t)}));
+      svg.appendChild(el("text", {x: margin.left - 6, y: y(t) + 4, "text-anchor": "end"}, fmt(t)));
+    }
+    svg.appendChild(el("text", {x: (width + margin.left) / 2, y: height - 10, "text-anchor": "middle"}, "memory per key (bytes)"));
+    svg.appendChild(el("text", {x: 15, y: height / 2, "text-anchor": "middle", transform: "rotate(-90 15 " + height / 2 + ")"}, "time per key (ns)"));
+
+    // the optimal points among the visible maps, sorted by memory
+    const visible = new Set(points.map(p => p.map));
+    const optimal = points.filter(p => !(p.dominatedBy || []).some(m => visible.has(m)))
+      .sort((a, b) => a.bytesPerEntry - b.bytesPerEntry);
+    svg.appendChild(el("polyline", {
+      points: optimal.map(p => x(p.bytesPerEntry) + "," + y(p.time)).join(" "),
+      fill: "none", stroke: "#999", "stroke-dasharray": "4 3",
+    }));
+    for (const p of points) {
+      const c = color(p.map);
+      const isOptimal = optimal.includes(p);
+      const dot = el("circle", {
+        cx: x(p.bytesPerEntry), cy: y(p.time), r: isOptimal ? 6 : 5,
+        fill: isOptimal ? c : "#fff", stroke: c, "stroke-width": 2,
+      });
+      let title = p.map + ": " + fmt(p.time) + " ns/key, " + fmt(p.bytesPerEntry) + " bytes/key";
+      if (!isOptimal) {
+        title += ", dominated by " + p.dominatedBy.filter(m => visible.has(m)).join(", ");
+      }
+      dot.appendChild(el("title", {}, title));
+      svg.appendChild(dot);
+      svg.appendChild(el("text", {x: x(p.bytesPerEntry) + 8, y: y(p.time) - 6}, p.map));
+    }
+  }
+
+  const paretoCharts = [];
+
+  function setupPareto() {
+    const root = document.getElementById("pareto");
+    if (!root || !report.pareto) {
+      return;
+    }
+    const byBenchmark = new Map();
+    for (const f of report.pareto) {
+      if (!byBenchmark.has(f.benchmark)) {
+        byBenchmark.set(f.benchmark, []);
+      }
+      byBenchmark.get(f.benchmark).push(f);
+    }
+    for (const [benchmark, frontiers] of byBenchmark) {
+      const div = document.createElement("div");
+      div.className = "chart";
+      const title = document.createElement("h3");
+      title.textContent = benchmark + " ";
+      const select = document.createElement("select");
+      frontiers.forEach(function (f, i) {
+        const opt = document.createElement("option");
+        opt.value = i;
+        opt.textContent = "n = " + f.size;
+        select.appendChild(opt);
+      });
+      select.value = frontiers.length - 1;
+      title.appendChild(select);
+      const svg = el("svg", {width: 960, height: 420});
+      div.append(title, svg);
+      root.appendChild(div);
+      const chart = {draw: () => drawFrontier(svg, frontiers[Number(select.value)])};
+      select.addEventListener("change", chart.draw);
+      paretoCharts.push(chart);
+    }
+  }
+
+  function drawAll() {
+    report.charts.forEach(draw);
+    paretoCharts.forEach(c => c.draw());
+  }
+
+  function setup() {
+    const maps = document.getElementById("maps");
+    for (const m of report.maps) {
+      const label = document.createElement("label");
+      const box = document.createElement("input");
+      box.type = "checkbox";
+      box.checked = true;
+      box.addEventListener("change", function () {
+        if (box.checked) {
+          state.hidden.delete(m);
+        } else {
+          state.hidden.add(m);
+        }
+        drawAll();
+      });
+      const swatch = document.createElement("span");
+      swatch.className = "swatch";
+      swatch.style.background = color(m);
+      label.append(box, swatch, m);
+      maps.appendChild(label);
+    }
+
+    const metrics = [];
+    for (const chart of report.charts) {
+      for (const v of chart.views) {
+        if (!metrics.find(m => m.metric === v.metric)) {
+          metrics.push(v);
+        }
+      }
+    }
+    const select = document.getElementById("metric");
+    for (const v of metrics) {
+      const opt = document.createElement("option");
+      opt.value = v.metric;
+      opt.textContent = v.label;
+      select.appendChild(opt);
+    }
+    state.metric = metrics.length > 0 ? metrics[0].metric : "";
+    select.addEventListener("change", function () {
+      state.metric = select.value;
+      drawAll();
+    });
+
+    const logX = document.getElementById("logx");
+    logX.addEventListener("change", function () {
+      state.logX = logX.checked;
+      drawAll();
+    });
+    const logY = document.getElementById("logy");
+    logY.addEventListener("change", function () {
+      state.logY = logY.checked;
+      drawAll();
+    });
+    setupPareto();
+    drawAll();
+  }
+
+  setup();
+})();
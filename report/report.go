@@ -0,0 +1,242 @@
+// Package report renders benchmark results as self-contained HTML document.
+package report
+
+import (
+	_ "embed"
+	"fmt"
+	"html/template"
+	"io"
+	"math"
+	"sort"
+
+	"bench-hashmaps/analysis"
+	"bench-hashmaps/cpucache"
+	"bench-hashmaps/result"
+)
+
+//go:embed report.html.tmpl
+var tmplText string
+
+//go:embed chart.js
+var chartJS string
+
+var tmpl = template.Must(template.New("report").Funcs(template.FuncMap{
+	"ratio":      formatRatio,
+	"ratioClass": ratioClass,
+}).Parse(tmplText))
+
+func formatRatio(v float64) string {
+	if math.IsNaN(v) {
+		return "-"
+	}
+	return fmt.Sprintf("%.2fx", v)
+}
+
+// ratioClass returns the css class of a ratio, better is true if a higher ratio is better.
+func ratioClass(v float64, better bool) string {
+	switch {
+	case math.IsNaN(v) || math.Abs(v-1) < 0.05:
+		return ""
+	case (v > 1) == better:
+		return "better"
+	default:
+		return "worse"
+	}
+}
+
+// View is a metric of a benchmark, which can be selected in the chart.
+type View struct {
+	Metric string `json:"metric"`
+	Label  string `json:"label"`
+	// Series contains one line per map.
+	Series []Series `json:"series"`
+}
+
+// Series are the data points of one map.
+type Series struct {
+	Map    string  `json:"map"`
+	Points []Point `json:"points"`
+}
+
+// Point is a data point of a series, Y is the mean and CI the half width
+// of its 95% confidence interval. TableBytes is the mean table-bytes metric, if reported.
+type Point struct {
+	X          int     `json:"x"`
+	Y          float64 `json:"y"`
+	CI         float64 `json:"ci"`
+	N          int     `json:"n"`
+	Load       float64 `json:"load"`
+	TableBytes float64 `json:"tableBytes,omitempty"`
+}
+
+// Chart contains all views of a benchmark.
+type Chart struct {
+	ID          string `json:"id"`
+	Benchmark   string `json:"benchmark"`
+	KeyType     string `json:"keyType"`
+	Scenario    string `json:"scenario"`
+	Description string `json:"description"`
+	Views       []View `json:"views"`
+	// TableBytes is true, if all points report the table bytes, e.g. of the cache sweeps. These charts
+	// are drawn over the table bytes and annotated with the cache levels.
+	TableBytes bool `json:"tableBytes"`
+}
+
+// Data is the content of the report.
+type Data struct {
+	Title    string
+	Metadata result.Metadata
+	Maps     []string
+	Charts   []Chart
+	// Speedup contains the ratios relative to a baseline map, if available.
+	Speedup *analysis.SpeedupTable
+	// Pareto contains the time versus memory frontiers, if available.
+	Pareto []analysis.Frontier
+	// Caches are the cache levels of the annotations, by default the caches of the metadata.
+	Caches []cpucache.Cache
+	// CacheDrops contains the slowdowns at the cache levels of the cache sweeps, if available.
+	CacheDrops []analysis.CacheDrop
+}
+
+// metricView describes how a metric is presented.
+type metricView struct {
+	metric string
+	label  string
+	scale  float64
+}
+
+// views are the selectable metrics of each chart, the first available is shown by default.
+var views = []metricView{
+	{"ns/key", "time per key (ns)", 1},
+	{"Mops/s", "throughput (Mops/s)", 1},
+	{"B/key", "memory per key (bytes)", 1},
+	{"ns/op", "time (ms)", 1e-6},
+	{"Bytes", "memory (MB)", 1.0 / (1024 * 1024)},
+	{"B/op", "allocated memory per op (MB)", 1.0 / (1024 * 1024)},
+	{"allocs/op", "allocations per op", 1},
+}
+
+// New prepares the report data of the run.
+func New(title string, run *result.Run) *Data {
+	d := &Data{Title: title, Metadata: run.Metadata, Caches: run.Metadata.Caches}
+	loads := meanLoads(run)
+	tables := meanTableBytes(run)
+
+	mapSeen := make(map[string]bool)
+	charts := make(map[string]*Chart)
+	var order []string
+	for _, mv := range views {
+		cells := analysis.Summarize(run, mv.metric)
+		for i := range cells {
+			c := &cells[i]
+			chart, ok := charts[c.Benchmark]
+			if !ok {
+				keyType, scenario := result.SplitBenchmark(c.Benchmark)
+				chart = &Chart{
+					ID:          c.Benchmark,
+					Benchmark:   c.Benchmark,
+					KeyType:     keyType,
+					Scenario:    scenario,
+					Description: Description(scenario),
+				}
+				charts[c.Benchmark] = chart
+				order = append(order, c.Benchmark)
+			}
+			if !mapSeen[c.Map] {
+				mapSeen[c.Map] = true
+				d.Maps = append(d.Maps, c.Map)
+			}
+			view := chart.view(mv)
+			series := view.series(c.Map)
+			series.Points = append(series.Points, Point{
+				X:          c.Size,
+				Y:          c.Mean * mv.scale,
+				CI:         c.CI * mv.scale,
+				N:          c.N,
+				Load:       loads[c.Key],
+				TableBytes: tables[c.Key],
+			})
+		}
+	}
+
+	sort.Strings(order)
+	for _, name := range order {
+		chart := charts[name]
+		chart.TableBytes = true
+		for i := range chart.Views {
+			for j := range chart.Views[i].Series {
+				points := chart.Views[i].Series[j].Points
+				sort.Slice(points, func(a, b int) bool { return points[a].X < points[b].X })
+				for _, p := range points {
+					chart.TableBytes = chart.TableBytes && p.TableBytes > 0
+				}
+			}
+		}
+		d.Charts = append(d.Charts, *chart)
+	}
+	return d
+}
+
+func meanLoads(run *result.Run) map[result.Key]float64 {
+	loads := make(map[result.Key]float64)
+	for _, c := range analysis.Summarize(run, "Load") {
+		loads[c.Key] = c.Mean
+	}
+	return loads
+}
+
+func meanTableBytes(run *result.Run) map[result.Key]float64 {
+	tables := make(map[result.Key]float64)
+	for _, c := range analysis.Summarize(run, "table-bytes") {
+		tables[c.Key] = c.Mean
+	}
+	return tables
+}
+
+func (c *Chart) view(mv metricView) *View {
+	for i := range c.Views {
+		if c.Views[i].Metric == mv.metric {
+			return &c.Views[i]
+		}
+	}
+	c.Views = append(c.Views, View{Metric: mv.metric, Label: mv.label})
+	return &c.Views[len(c.Views)-1]
+}
+
+func (v *View) series(mapName string) *Series {
+	for i := range v.Series {
+		if v.Series[i].Map == mapName {
+			return &v.Series[i]
+		}
+	}
+	v.Series = append(v.Series, Series{Map: mapName})
+	return &v.Series[len(v.Series)-1]
+}
+
+// AddSpeedup adds the speedup and memory tables relative to the baseline map to the report.
+func (d *Data) AddSpeedup(run *result.Run, baseline string) error {
+	t, err := analysis.Speedup(run, baseline)
+	if err != nil {
+		return err
+	}
+	d.Speedup = t
+	return nil
+}
+
+// AddPareto adds the Pareto frontiers of time versus memory per entry to the report.
+func (d *Data) AddPareto(run *result.Run) {
+	d.Pareto = analysis.Pareto(run)
+}
+
+// AddCacheDrops adds the slowdowns of the cache sweeps at the cache levels of the report.
+func (d *Data) AddCacheDrops(run *result.Run) {
+	d.CacheDrops = analysis.CacheDrops(run, d.Caches)
+}
+
+// Render writes the report as HTML document to w.
+func (d *Data) Render(w io.Writer) error {
+	return tmpl.Execute(w, struct {
+		*Data
+		Script template.JS
+	}{d, template.JS(chartJS)})
+}
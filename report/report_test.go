@@ -0,0 +1,81 @@
+package report
+
+import (
+	"bytes"
+	"html/template"
+	"strings"
+	"testing"
+
+	"bench-hashmaps/cpucache"
+	"bench-hashmaps/result"
+)
+
+func record(benchmark, mapName string, size int, metrics map[string]float64) result.Record {
+	keyType, scenario := result.SplitBenchmark(benchmark)
+	return result.Record{
+		Benchmark: benchmark,
+		Scenario:  scenario,
+		KeyType:   keyType,
+		Map:       mapName,
+		Size:      size,
+		Metrics:   metrics,
+	}
+}
+
+func TestRender(t *testing.T) {
+	sweep := func(mapName string, size int, table, time float64) result.Record {
+		return record("BenchmarkU64CacheSweep", mapName, size, map[string]float64{"ns/key": time, "table-bytes": table})
+	}
+	run := &result.Run{
+		Metadata: result.Metadata{CPU: "Test CPU", Caches: []cpucache.Cache{
+			{Level: 1, Type: "Data", Size: 1024},
+			{Level: 2, Type: "Unified", Size: 1 << 20},
+		}},
+		Records: []result.Record{
+			record("BenchmarkU64FullReads", "std", 1000, map[string]float64{"ns/op": 20000, "ns/key": 20, "B/key": 40}),
+			record("BenchmarkU64FullReads", "swiss", 1000, map[string]float64{"ns/op": 10000, "ns/key": 10, "B/key": 30}),
+			sweep("swiss", 10, 500, 1),
+			sweep("swiss", 20, 900, 1.1),
+			sweep("swiss", 30, 1300, 2),
+			sweep("swiss", 40, 1800, 2.5),
+		},
+	}
+	d := New("test report", run)
+	if err := d.AddSpeedup(run, "std"); err != nil {
+		t.Fatal(err)
+	}
+	d.AddPareto(run)
+	d.AddCacheDrops(run)
+	if len(d.CacheDrops) == 0 {
+		t.Fatal("no cache drops found")
+	}
+
+	var buf bytes.Buffer
+	if err := d.Render(&buf); err != nil {
+		t.Fatal(err)
+	}
+	out := buf.String()
+	tests := []struct {
+		name string
+		want string
+	}{
+		{"title", "<title>test report</title>"},
+		{"metadata", "cpu: Test CPU"},
+		{"inlined chart.js", chartJS},
+		{"scenario notes", template.HTMLEscapeString(Description("FullReads"))},
+		{"cache boundaries", "<h2>Cache boundaries</h2>"},
+		{"cache drop", "<td>L1 1K</td>"},
+		{"cache annotations", `caches: [{"level":1,"type":"Data","size":1024}`},
+		{"sweep over the table bytes", `"tableBytes":true`},
+	}
+	for _, tt := range tests {
+		if !strings.Contains(out, tt.want) {
+			t.Errorf("%s: %q not found in the report", tt.name, tt.want)
+		}
+	}
+	for _, external := range []string{"<script src", "<link", "https://"} {
+		if strings.Contains(out, external) {
+			t.Errorf("report references an external resource: %q", external)
+		}
+	}
+}
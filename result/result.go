@@ -0,0 +1,246 @@
+// Package result provides a structured representation of the benchmark results
+// produced by this repository.
+package result
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	"bench-hashmaps/cpucache"
+)
+
+// Metadata describes the environment of a single benchmark run.
+type Metadata struct {
+	GoOS       string `json:"goos,omitempty"`
+	GoArch     string `json:"goarch,omitempty"`
+	Pkg        string `json:"pkg,omitempty"`
+	CPU        string `json:"cpu,omitempty"`
+	Hostname   string `json:"hostname,omitempty"`
+	GoVersion  string `json:"goVersion,omitempty"`
+	GoMaxProcs int    `json:"gomaxprocs,omitempty"`
+	Seed       int64  `json:"seed"`
+	Dataset    string `json:"dataset,omitempty"`
+	KeyPattern string `json:"keyPattern,omitempty"`
+	Start      string `json:"start,omitempty"`
+	End        string `json:"end,omitempty"`
+	// Caches are the data caches of the CPU, which define the sizes of the cache sweep.
+	Caches []cpucache.Cache `json:"caches,omitempty"`
+}
+
+// Record is a single result line of a sub-benchmark.
+type Record struct {
+	// Benchmark is the full name of the top level benchmark, e.g. BenchmarkU64FullReads.
+	Benchmark string `json:"benchmark"`
+	// Scenario is the benchmark name without prefix and key type, e.g. FullReads.
+	Scenario string `json:"scenario"`
+	// KeyType is the key type of the benchmark, e.g. U64.
+	KeyType string `json:"keyType"`
+	// Map is the name of the benchmarked hash map, e.g. robinLowLoad.
+	Map string `json:"map"`
+	// Size is the number of elements (n) used by the benchmark.
+	Size int `json:"size"`
+	// Procs is the GOMAXPROCS suffix of the benchmark name.
+	Procs int `json:"procs"`
+	// Iterations is the number of iterations (b.N).
+	Iterations int `json:"iterations"`
+	// Metrics contains all reported metrics by unit, e.g. "ns/op".
+	Metrics map[string]float64 `json:"metrics"`
+}
+
+// Run is the result of a whole benchmark run.
+type Run struct {
+	Metadata Metadata `json:"metadata"`
+	Records  []Record `json:"records"`
+}
+
+// HigherIsBetter reports whether a higher value of the metric is better, e.g. for throughputs.
+func HigherIsBetter(metric string) bool {
+	return metric == "Mops/s" || metric == "MB/s"
+}
+
+// Derive adds the per key metrics ns/key, Mops/s and B/key to all records, which do not
+// contain them yet, e.g. results of older runs. The number of keys is taken from the
+// N-runs metric or the size of the benchmark.
+func (r *Run) Derive() {
+	for i := range r.Records {
+		rec := &r.Records[i]
+		n, ok := rec.Metrics["N-runs"]
+		if !ok {
+			n = float64(rec.Size)
+		}
+		if n <= 0 {
+			continue
+		}
+		if ns, ok := rec.Metrics["ns/op"]; ok {
+			if _, found := rec.Metrics["ns/key"]; !found {
+				rec.Metrics["ns/key"] = ns / n
+			}
+			if _, found := rec.Metrics["Mops/s"]; !found && ns > 0 {
+				rec.Metrics["Mops/s"] = n / ns * 1e3
+			}
+		}
+		if bytes, ok := rec.Metrics["Bytes"]; ok {
+			if _, found := rec.Metrics["B/key"]; !found {
+				rec.Metrics["B/key"] = bytes / n
+			}
+		}
+	}
+}
+
+// keyTypes are the known key type prefixes of the benchmark names.
+var keyTypes = []string{"U32", "U64", "UUID"}
+
+// SplitBenchmark splits a benchmark name like "BenchmarkU64FullReads" into
+// the key type "U64" and the scenario "FullReads". For nested benchmarks like
+// "BenchmarkSpec/UUIDZipfReads", the last element is split.
+func SplitBenchmark(benchmark string) (keyType, scenario string) {
+	scenario = strings.TrimPrefix(benchmark, "Benchmark")
+	if i := strings.LastIndex(scenario, "/"); i >= 0 {
+		scenario = scenario[i+1:]
+	}
+	for _, kt := range keyTypes {
+		if strings.HasPrefix(scenario, kt) {
+			return kt, strings.TrimPrefix(scenario, kt)
+		}
+	}
+	return "", scenario
+}
+
+// ParseName splits a benchmark name like "BenchmarkU64FullReads/robinLowLoad-400000-8"
+// into its components. The GOMAXPROCS suffix is optional. Nested benchmarks like
+// "BenchmarkSpec/UUIDZipfReads/std-1000000" keep all but the last element as benchmark.
+func ParseName(name string) (Record, error) {
+	var rec Record
+	i := strings.LastIndex(name, "/")
+	if i < 0 || !strings.HasPrefix(name, "Benchmark") {
+		return rec, fmt.Errorf("invalid benchmark name: %q", name)
+	}
+	top, sub := name[:i], name[i+1:]
+	rec.Benchmark = top
+	rec.KeyType, rec.Scenario = SplitBenchmark(top)
+
+	parts := strings.Split(sub, "-")
+	if len(parts) < 2 {
+		return rec, fmt.Errorf("invalid sub-benchmark name: %q", name)
+	}
+	rec.Procs = 1
+	if len(parts) > 2 {
+		procs, err := strconv.Atoi(parts[len(parts)-1])
+		if err == nil {
+			rec.Procs = procs
+			parts = parts[:len(parts)-1]
+		}
+	}
+	size, err := strconv.Atoi(parts[len(parts)-1])
+	if err != nil {
+		return rec, fmt.Errorf("invalid size in benchmark name %q: %w", name, err)
+	}
+	rec.Size = size
+	rec.Map = strings.Join(parts[:len(parts)-1], "-")
+	return rec, nil
+}
+
+// ParseLine parses a single result line of the go test benchmark output.
+func ParseLine(line string) (Record, error) {
+	fields := strings.Fields(line)
+	if len(fields) < 2 || len(fields)%2 != 0 {
+		return Record{}, fmt.Errorf("invalid benchmark line: %q", line)
+	}
+	rec, err := ParseName(fields[0])
+	if err != nil {
+		return rec, err
+	}
+	rec.Iterations, err = strconv.Atoi(fields[1])
+	if err != nil {
+		return rec, fmt.Errorf("invalid iteration count in line %q: %w", line, err)
+	}
+	rec.Metrics = make(map[string]float64, (len(fields)-2)/2)
+	for i := 2; i < len(fields); i += 2 {
+		v, err := strconv.ParseFloat(fields[i], 64)
+		if err != nil {
+			return rec, fmt.Errorf("invalid metric value in line %q: %w", line, err)
+		}
+		rec.Metrics[fields[i+1]] = v
+	}
+	return rec, nil
+}
+
+// Name returns the full sub-benchmark name of the record, e.g. "BenchmarkU64FullReads/robin-400000-8".
+// Like the go testing package, the GOMAXPROCS suffix is omitted if it is 1.
+func (rec *Record) Name() string {
+	name := fmt.Sprintf("%s/%s-%d", rec.Benchmark, rec.Map, rec.Size)
+	if rec.Procs > 1 {
+		name += fmt.Sprintf("-%d", rec.Procs)
+	}
+	return name
+}
+
+// Key identifies the measurements of one map in one sub-benchmark.
+type Key struct {
+	Benchmark string
+	Map       string
+	Size      int
+}
+
+// Key returns the key of the record.
+func (rec *Record) Key() Key {
+	return Key{Benchmark: rec.Benchmark, Map: rec.Map, Size: rec.Size}
+}
+
+// Group collects the values of the given metric of all records with the same key.
+// The keys are returned in the order of their first appearance.
+func (r *Run) Group(metric string) ([]Key, map[Key][]float64) {
+	var keys []Key
+	groups := make(map[Key][]float64)
+	for i := range r.Records {
+		rec := &r.Records[i]
+		v, ok := rec.Metrics[metric]
+		if !ok {
+			continue
+		}
+		k := rec.Key()
+		if _, found := groups[k]; !found {
+			keys = append(keys, k)
+		}
+		groups[k] = append(groups[k], v)
+	}
+	return keys, groups
+}
+
+// IsResultLine reports whether the line looks like a benchmark result line.
+func IsResultLine(line string) bool {
+	return strings.HasPrefix(line, "Benchmark") && strings.Contains(line, "\t")
+}
+
+// WriteJSON writes the run as an indented JSON document to w.
+func (r *Run) WriteJSON(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(r)
+}
+
+// ReadJSON reads a run from a JSON document.
+func ReadJSON(r io.Reader) (*Run, error) {
+	run := &Run{}
+	if err := json.NewDecoder(r).Decode(run); err != nil {
+		return nil, err
+	}
+	return run, nil
+}
+
+// WriteJSONFile writes the run as JSON document to the given path.
+func (r *Run) WriteJSONFile(path string) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := r.WriteJSON(f); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
@@ -0,0 +1,122 @@
+package result
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseName(t *testing.T) {
+	tests := []struct {
+		name string
+		want Record
+	}{
+		{"BenchmarkU64FullReads/robinLowLoad-400000-8", Record{Benchmark: "BenchmarkU64FullReads", Scenario: "FullReads", KeyType: "U64", Map: "robinLowLoad", Size: 400000, Procs: 8}},
+		{"BenchmarkUUIDReadsMisses/std-50000", Record{Benchmark: "BenchmarkUUIDReadsMisses", Scenario: "ReadsMisses", KeyType: "UUID", Map: "std", Size: 50000, Procs: 1}},
+		{"BenchmarkSpec/UUIDZipfReads/std-1000000-4", Record{Benchmark: "BenchmarkSpec/UUIDZipfReads", Scenario: "ZipfReads", KeyType: "UUID", Map: "std", Size: 1000000, Procs: 4}},
+		{"BenchmarkU32_50Reads_25Inserts_25Deletes/swiss-100-2", Record{Benchmark: "BenchmarkU32_50Reads_25Inserts_25Deletes", Scenario: "_50Reads_25Inserts_25Deletes", KeyType: "U32", Map: "swiss", Size: 100, Procs: 2}},
+	}
+	for _, tt := range tests {
+		got, err := ParseName(tt.name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("ParseName(%q) = %+v, want %+v", tt.name, got, tt.want)
+		}
+		if got.Name() != strings.TrimSuffix(tt.name, "-1") {
+			t.Errorf("Name() = %q, want %q", got.Name(), tt.name)
+		}
+	}
+
+	for _, name := range []string{"BenchmarkU64FullReads", "U64FullReads/std-100", "BenchmarkU64FullReads/std"} {
+		if _, err := ParseName(name); err == nil {
+			t.Errorf("ParseName(%q) expected error", name)
+		}
+	}
+}
+
+func TestReadSplitLine(t *testing.T) {
+	in := "BenchmarkU64FullReads/std-1000-8   \t\n" +
+		"    bench_test.go:1: some log output\n" +
+		"       2\t      9297 ns/op\t      1000 N-runs\n" +
+		"PASS\n"
+	run, err := Read(strings.NewReader(in))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(run.Records) != 1 {
+		t.Fatalf("got %d records, want 1", len(run.Records))
+	}
+	if rec := run.Records[0]; rec.Map != "std" || rec.Metrics["ns/op"] != 9297 || rec.Metrics["N-runs"] != 1000 {
+		t.Errorf("unexpected record: %+v", rec)
+	}
+}
+
+func TestReadLenient(t *testing.T) {
+	in := "BenchmarkU64FullReads/std-1000-8\t2\t9297 ns/op\n" +
+		"BenchmarkU64FullReads/std-x-8\t2\t9297 ns/op\n" +
+		"BenchmarkU64FullReads/swiss-1000-8\t2\t8000 ns/op\n"
+	if _, err := Read(strings.NewReader(in)); err == nil {
+		t.Error("Read of an invalid line succeeded")
+	}
+	var skipped []error
+	run, err := ReadLenient(strings.NewReader(in), func(err error) { skipped = append(skipped, err) })
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(run.Records) != 2 || len(skipped) != 1 || !strings.Contains(skipped[0].Error(), "line 2") {
+		t.Errorf("got %d records and skipped %v, want 2 records and line 2 skipped", len(run.Records), skipped)
+	}
+}
+
+func TestRoundTrip(t *testing.T) {
+	files, err := filepath.Glob("../results/*.out")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) == 0 {
+		t.Skip("no result files found")
+	}
+	for _, file := range files {
+		f, err := os.Open(file)
+		if err != nil {
+			t.Fatal(err)
+		}
+		run, err := Read(f)
+		f.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(run.Records) == 0 || run.Metadata.CPU == "" {
+			t.Fatalf("%s: no records or metadata parsed", file)
+		}
+
+		var text bytes.Buffer
+		if err := run.Write(&text); err != nil {
+			t.Fatal(err)
+		}
+		fromText, err := Read(&text)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(run, fromText) {
+			t.Errorf("%s: text round trip mismatch", file)
+		}
+
+		var js bytes.Buffer
+		if err := run.WriteJSON(&js); err != nil {
+			t.Fatal(err)
+		}
+		fromJSON, err := ReadJSON(&js)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(run, fromJSON) {
+			t.Errorf("%s: json round trip mismatch", file)
+		}
+	}
+}
@@ -0,0 +1,183 @@
+package result
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// Read reads the raw go test benchmark output from r, e.g. the files in results/*.out.
+// Header lines like "cpu: ..." are stored in the metadata, all other non result lines are ignored.
+// Read stops at the first unparsable result line.
+func Read(r io.Reader) (*Run, error) {
+	return read(r, func(err error) error { return err })
+}
+
+// ReadLenient is like Read, but it passes the errors of unparsable result lines to skip and continues
+// with the next line. It is used for the output of long runs, which must not be lost because of one line.
+func ReadLenient(r io.Reader, skip func(err error)) (*Run, error) {
+	return read(r, func(err error) error {
+		skip(err)
+		return nil
+	})
+}
+
+// read reads the benchmark output, onError decides whether an unparsable line stops the reading.
+func read(r io.Reader, onError func(err error) error) (*Run, error) {
+	run := &Run{}
+	sc := bufio.NewScanner(r)
+	sc.Buffer(make([]byte, 64*1024), 1024*1024)
+	// pending holds a benchmark name, which was printed without results
+	// because the benchmark wrote additional output (e.g. b.Log).
+	pending := ""
+	for lineNo := 1; sc.Scan(); lineNo++ {
+		line := sc.Text()
+		if IsResultLine(line) {
+			if len(strings.Fields(line)) == 1 {
+				pending = strings.TrimSpace(line)
+				continue
+			}
+			pending = ""
+		} else if pending != "" && startsWithDigit(line) {
+			line = pending + "\t" + line
+			pending = ""
+		} else {
+			run.Metadata.parseHeader(line)
+			continue
+		}
+
+		rec, err := ParseLine(line)
+		if err != nil {
+			if err := onError(fmt.Errorf("line %d: %w", lineNo, err)); err != nil {
+				return run, err
+			}
+			continue
+		}
+		run.Records = append(run.Records, rec)
+	}
+	return run, sc.Err()
+}
+
+func startsWithDigit(line string) bool {
+	line = strings.TrimSpace(line)
+	return line != "" && line[0] >= '0' && line[0] <= '9'
+}
+
+func (meta *Metadata) parseHeader(line string) {
+	key, value, found := strings.Cut(line, ": ")
+	if !found {
+		return
+	}
+	switch key {
+	case "goos":
+		meta.GoOS = value
+	case "goarch":
+		meta.GoArch = value
+	case "pkg":
+		meta.Pkg = value
+	case "cpu":
+		meta.CPU = value
+	}
+}
+
+// Write writes the run in the go test benchmark output format, which can be read again by Read.
+func (r *Run) Write(w io.Writer) error {
+	bw := bufio.NewWriter(w)
+	header := []struct{ key, value string }{
+		{"goos", r.Metadata.GoOS},
+		{"goarch", r.Metadata.GoArch},
+		{"pkg", r.Metadata.Pkg},
+		{"cpu", r.Metadata.CPU},
+	}
+	for _, h := range header {
+		if h.value != "" {
+			fmt.Fprintf(bw, "%s: %s\n", h.key, h.value)
+		}
+	}
+
+	maxLen := 0
+	for i := range r.Records {
+		if l := len(r.Records[i].Name()); l > maxLen {
+			maxLen = l
+		}
+	}
+	for i := range r.Records {
+		rec := &r.Records[i]
+		fmt.Fprintf(bw, "%-*s\t%8d", maxLen, rec.Name(), rec.Iterations)
+		if ns, ok := rec.Metrics["ns/op"]; ok {
+			bw.WriteByte('\t')
+			prettyPrint(bw, ns, "ns/op")
+		}
+		units := make([]string, 0, len(rec.Metrics))
+		for unit := range rec.Metrics {
+			switch unit {
+			case "ns/op", "B/op", "allocs/op":
+				continue
+			}
+			units = append(units, unit)
+		}
+		sort.Strings(units)
+		for _, unit := range units {
+			bw.WriteByte('\t')
+			prettyPrint(bw, rec.Metrics[unit], unit)
+		}
+		for _, unit := range []string{"B/op", "allocs/op"} {
+			if v, ok := rec.Metrics[unit]; ok {
+				fmt.Fprintf(bw, "\t%8.0f %s", v, unit)
+			}
+		}
+		bw.WriteByte('\n')
+	}
+	return bw.Flush()
+}
+
+// prettyPrint formats the metric like the go testing package.
+func prettyPrint(w io.Writer, x float64, unit string) {
+	var format string
+	switch y := math.Abs(x); {
+	case y == 0 || y >= 999.95:
+		format = "%10.0f %s"
+	case y >= 99.995:
+		format = "%12.1f %s"
+	case y >= 9.9995:
+		format = "%13.2f %s"
+	case y >= 0.99995:
+		format = "%14.3f %s"
+	case y >= 0.099995:
+		format = "%15.4f %s"
+	case y >= 0.0099995:
+		format = "%16.5f %s"
+	case y >= 0.00099995:
+		format = "%17.6f %s"
+	default:
+		format = "%18.7f %s"
+	}
+	fmt.Fprintf(w, format, x, unit)
+}
+
+// Load reads a result file. Files with the extension ".json" are read as JSON document,
+// all other files as raw go test benchmark output. Missing per key metrics are derived, see Run.Derive.
+func Load(path string) (*Run, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var run *Run
+	if filepath.Ext(path) == ".json" {
+		run, err = ReadJSON(f)
+	} else {
+		run, err = Read(f)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	run.Derive()
+	return run, nil
+}
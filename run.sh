@@ -5,5 +5,8 @@ SCRIPT_DIR="$(realpath $(dirname $0))"
 trap 'trap - SIGINT; kill -SIGINT $$' SIGINT;
 
 cd $SCRIPT_DIR
-# pass environment variables to support benchmark configuration
-RANGES="$RANGES" MAPS="$MAPS" go test -bench=.  -benchtime=2x -timeout 120m
+# number of independent repetitions of each benchmark, used to scale the timeout
+COUNT=${COUNT:-1}
+# pass environment variables and arguments (e.g. -config bench.json -name nightly) to support benchmark configuration
+RANGES="$RANGES" MAPS="$MAPS" SEED="$SEED" JSON_OUT="$JSON_OUT" SHUFFLE="$SHUFFLE" COUNT="$COUNT" \
+	go test -bench=. -timeout $((120 * COUNT))m -args "$@"
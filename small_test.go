@@ -0,0 +1,225 @@
+package bench_test
+
+import (
+	"fmt"
+	"runtime"
+	"testing"
+	"time"
+	"unsafe"
+
+	"github.com/EinfachAndy/hashmaps"
+)
+
+const (
+	// smallEntries limits the total number of entries of the small maps, the number of maps is
+	// cfg.SmallMaps for tiny sizes and smaller for the larger sizes.
+	smallEntries = 1 << 21
+	// smallPool is the maximal number of distinct keys, which are shared by the small maps.
+	smallPool = 1 << 16
+)
+
+// smallMapCount returns the number of maps of the given size.
+func smallMapCount(size int) int {
+	if size > 0 && smallEntries/size < cfg.SmallMaps {
+		return smallEntries / size
+	}
+	return cfg.SmallMaps
+}
+
+// smallKeys returns the keys of the i-th small map, a window of the pool.
+func smallKeys[K any](pool []K, i, size int) []K {
+	start := 0
+	if len(pool) > size {
+		start = i * size % (len(pool) - size)
+	}
+	return pool[start : start+size]
+}
+
+// genSmallPool returns the distinct keys of the small maps of the given size, at least one.
+func genSmallPool[K any](size int, gen func(int) []K) []K {
+	n := size * smallMapCount(size)
+	if n > smallPool {
+		n = smallPool
+	}
+	if n < size {
+		n = size
+	}
+	if n == 0 {
+		n = 1
+	}
+	return gen(n)
+}
+
+func BenchmarkU64SmallInserts(b *testing.B) {
+	for _, size := range cfg.SmallSizes {
+		runSmallInserts(b, size, genSmallPool(size, genRandIntArray[uint64]))
+	}
+}
+
+func BenchmarkUUIDSmallInserts(b *testing.B) {
+	for _, size := range cfg.SmallSizes {
+		runSmallInserts(b, size, genSmallPool(size, genUUIDArray))
+	}
+}
+
+func BenchmarkU64SmallReads(b *testing.B) {
+	for _, size := range cfg.SmallSizes {
+		runSmallReads(b, size, genSmallPool(size, genRandIntArray[uint64]))
+	}
+}
+
+func BenchmarkUUIDSmallReads(b *testing.B) {
+	for _, size := range cfg.SmallSizes {
+		runSmallReads(b, size, genSmallPool(size, genUUIDArray))
+	}
+}
+
+func BenchmarkU64SmallIteration(b *testing.B) {
+	for _, size := range cfg.SmallSizes {
+		runSmallIteration(b, size, genSmallPool(size, genRandIntArray[uint64]))
+	}
+}
+
+func BenchmarkUUIDSmallIteration(b *testing.B) {
+	for _, size := range cfg.SmallSizes {
+		runSmallIteration(b, size, genSmallPool(size, genUUIDArray))
+	}
+}
+
+// buildSmallMaps creates count maps and inserts the keys of each map.
+func buildSmallMaps[K ordered](mapName string, count, size int, pool []K) []hashmaps.IHashMap[K, uint64] {
+	maps := make([]hashmaps.IHashMap[K, uint64], count)
+	for i := range maps {
+		maps[i] = createMap[K, uint64](0, mapName)
+		for _, k := range smallKeys(pool, i, size) {
+			maps[i].Put(k, 1)
+		}
+	}
+	return maps
+}
+
+// runSmallInserts measures the construction of the maps and the inserts separately. The memory per map
+// is the retained heap of the maps, which includes the 16 byte Get closure of the createMap adapter.
+func runSmallInserts[K ordered](b *testing.B, size int, pool []K) {
+	count := smallMapCount(size)
+	for _, mapName := range getMapNames() {
+		b.Run(fmt.Sprintf("%s-%d", mapName, size), func(b *testing.B) {
+			var construct, insert time.Duration
+			var constructAllocs, insertAllocs uint64
+			var retained int64
+			var mem runtime.MemStats
+			load := float32(-1.0)
+			for i := 0; i < b.N; i++ {
+				b.StopTimer()
+				runtime.GC()
+				runtime.ReadMemStats(&mem)
+				base := int64(mem.HeapAlloc)
+				gets := make([]func(K) (uint64, bool), count)
+				maps := make([]hashmaps.IHashMap[K, uint64], count)
+				runtime.ReadMemStats(&mem)
+				mallocs := mem.Mallocs
+				b.StartTimer()
+
+				start := time.Now()
+				for j := range maps {
+					maps[j] = createMap[K, uint64](0, mapName)
+				}
+				construct += time.Since(start)
+				b.StopTimer()
+				runtime.ReadMemStats(&mem)
+				constructAllocs += mem.Mallocs - mallocs
+				mallocs = mem.Mallocs
+				b.StartTimer()
+
+				start = time.Now()
+				for j := range maps {
+					for _, k := range smallKeys(pool, j, size) {
+						maps[j].Put(k, 1)
+					}
+				}
+				insert += time.Since(start)
+				b.StopTimer()
+				runtime.ReadMemStats(&mem)
+				insertAllocs += mem.Mallocs - mallocs
+				load = maps[0].Load()
+
+				// keep only the maps and their Get closures alive to measure the retained heap
+				for j := range maps {
+					gets[j] = maps[j].Get
+				}
+				maps = nil
+				runtime.GC()
+				runtime.ReadMemStats(&mem)
+				retained += int64(mem.HeapAlloc) - base - int64(count)*int64(unsafe.Sizeof(gets[0]))
+				runtime.KeepAlive(gets)
+			}
+			report(b, count*size, load)
+			perMap := float64(b.N * count)
+			b.ReportMetric(float64(construct.Nanoseconds()+insert.Nanoseconds())/perMap, "ns/map")
+			b.ReportMetric(float64(construct.Nanoseconds())/perMap, "construct-ns/map")
+			b.ReportMetric(float64(insert.Nanoseconds())/perMap, "insert-ns/map")
+			b.ReportMetric(float64(constructAllocs)/perMap, "construct-allocs/map")
+			b.ReportMetric(float64(insertAllocs)/perMap, "insert-allocs/map")
+			b.ReportMetric(float64(retained)/perMap, "B/map")
+		})
+	}
+}
+
+// runSmallReads looks up all keys of all maps, empty maps are looked up with a missing key.
+func runSmallReads[K ordered](b *testing.B, size int, pool []K) {
+	count := smallMapCount(size)
+	for _, mapName := range getMapNames() {
+		b.Run(fmt.Sprintf("%s-%d", mapName, size), func(b *testing.B) {
+			load := float32(-1.0)
+			for i := 0; i < b.N; i++ {
+				b.StopTimer()
+				maps := buildSmallMaps(mapName, count, size, pool)
+				runtime.GC()
+				b.StartTimer()
+
+				for j := range maps {
+					if size == 0 {
+						if _, found := maps[j].Get(pool[0]); found {
+							b.Fatal("key found in empty map")
+						}
+						continue
+					}
+					for _, k := range smallKeys(pool, j, size) {
+						if _, found := maps[j].Get(k); !found {
+							b.Fatal("inserted key not found")
+						}
+					}
+				}
+				b.StopTimer()
+
+				load = maps[0].Load()
+			}
+			report(b, count*size, load)
+			b.ReportMetric(float64(b.Elapsed().Nanoseconds())/float64(b.N*count), "ns/map")
+		})
+	}
+}
+
+func runSmallIteration[K ordered](b *testing.B, size int, pool []K) {
+	count := smallMapCount(size)
+	for _, mapName := range getMapNames() {
+		b.Run(fmt.Sprintf("%s-%d", mapName, size), func(b *testing.B) {
+			load := float32(-1.0)
+			for i := 0; i < b.N; i++ {
+				b.StopTimer()
+				maps := buildSmallMaps(mapName, count, size, pool)
+				runtime.GC()
+				b.StartTimer()
+
+				for j := range maps {
+					maps[j].Each(handleElem[K, uint64])
+				}
+				b.StopTimer()
+
+				load = maps[0].Load()
+			}
+			report(b, count*size, load)
+			b.ReportMetric(float64(b.Elapsed().Nanoseconds())/float64(b.N*count), "ns/map")
+		})
+	}
+}
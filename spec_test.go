@@ -0,0 +1,102 @@
+package bench_test
+
+import (
+	"fmt"
+	"math/rand"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"golang.org/x/exp/constraints"
+
+	"bench-hashmaps/workload"
+)
+
+// BenchmarkSpec runs the declarative workload specs of the configuration, see package workload.
+// The sub-benchmarks are named by the key type and name of the spec, e.g. BenchmarkSpec/UUIDZipfReads/std-1000000.
+func BenchmarkSpec(b *testing.B) {
+	paths, err := filepath.Glob(cfg.Specs)
+	if err != nil {
+		b.Fatal(err)
+	}
+	for _, path := range paths {
+		spec, err := workload.Load(path)
+		if err != nil {
+			b.Fatal(err)
+		}
+		if !cfg.selects(spec.KeyType, spec.Name, "Spec") {
+			continue
+		}
+		b.Run(spec.KeyType+spec.Name, func(b *testing.B) {
+			if keySet != nil && !datasetSupports(spec.KeyType) {
+				b.Skipf("dataset %s does not support key type %s", keySet.Path, spec.KeyType)
+			}
+			sizes := getRanges()
+			if spec.Prefill > 0 {
+				sizes = []int{spec.Prefill}
+			}
+			for _, r := range sizes {
+				switch spec.KeyType {
+				case "U32":
+					keys, misses := genSpecIntKeys[uint32](spec, r)
+					runSpec(b, spec, r, keys, misses)
+				case "U64":
+					keys, misses := genSpecIntKeys[uint64](spec, r)
+					runSpec(b, spec, r, keys, misses)
+				case "UUID":
+					keys := genUUIDArray(spec.KeyCount(r))
+					var misses []string
+					if keySet != nil {
+						misses = datasetStrings(spec.MissCount(r), keys)
+					} else {
+						misses = genUUIDArray(spec.MissCount(r))
+					}
+					runSpec(b, spec, r, keys, misses)
+				}
+			}
+		})
+	}
+}
+
+func genSpecIntKeys[K constraints.Integer](spec *workload.Spec, r int) ([]K, []K) {
+	var keys []K
+	switch spec.Generator {
+	case "shuffled":
+		keys = genShuffledIntArray[K](spec.KeyCount(r))
+	default: // random, the generators are checked by the validation of the spec
+		keys = genRandIntArray[K](spec.KeyCount(r))
+	}
+	if keySet != nil {
+		return keys, datasetInts(spec.MissCount(r), keys)
+	}
+	misses := genDifferentRandIntArray(keys)
+	if n := spec.MissCount(r); n < len(misses) {
+		misses = misses[:n]
+	}
+	return keys, misses
+}
+
+func runSpec[K ordered](b *testing.B, spec *workload.Spec, r int, keys, misses []K) {
+	prog, err := workload.Compile(spec, r, keys, misses, rand.New(rand.NewSource(rand.Int63())))
+	if err != nil {
+		b.Fatalf("%s: %v", spec.Name, err)
+	}
+	for _, mapName := range getMapNames() {
+		b.Run(fmt.Sprintf("%s-%d", mapName, r), func(b *testing.B) {
+			load := float32(-1.0)
+			for i := 0; i < b.N; i++ {
+				b.StopTimer()
+
+				m := createMap[K, uint64](0, mapName)
+				results := prog.Run(m, b)
+				if err := prog.Check(results); err != nil {
+					b.Errorf("%s: assertion failed: %v", mapName, err)
+				}
+				runtime.GC() // more accurate memory tracking
+
+				load = m.Load()
+			}
+			report(b, prog.TimedOps(), load)
+		})
+	}
+}
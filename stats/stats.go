@@ -0,0 +1,277 @@
+// Package stats provides the statistical helpers to summarize and compare benchmark samples.
+package stats
+
+import (
+	"math"
+	"sort"
+)
+
+// Summary describes a sample of benchmark measurements.
+type Summary struct {
+	N      int
+	Mean   float64
+	StdDev float64
+	// CI is the half width of the 95% confidence interval of the mean.
+	CI float64
+}
+
+// Summarize computes the summary of the values.
+func Summarize(values []float64) Summary {
+	s := Summary{N: len(values)}
+	if s.N == 0 {
+		return s
+	}
+	s.Mean = Mean(values)
+	s.StdDev = StdDev(values)
+	if s.N > 1 {
+		s.CI = TQuantile(0.975, float64(s.N-1)) * s.StdDev / math.Sqrt(float64(s.N))
+	}
+	return s
+}
+
+// CV returns the coefficient of variation of the summary.
+func (s Summary) CV() float64 {
+	if s.Mean == 0 {
+		return 0
+	}
+	return s.StdDev / math.Abs(s.Mean)
+}
+
+// Mean returns the arithmetic mean of the values.
+func Mean(values []float64) float64 {
+	if len(values) == 0 {
+		return math.NaN()
+	}
+	sum := 0.0
+	for _, v := range values {
+		sum += v
+	}
+	return sum / float64(len(values))
+}
+
+// StdDev returns the sample standard deviation of the values.
+func StdDev(values []float64) float64 {
+	if len(values) < 2 {
+		return 0
+	}
+	m := Mean(values)
+	sum := 0.0
+	for _, v := range values {
+		sum += (v - m) * (v - m)
+	}
+	return math.Sqrt(sum / float64(len(values)-1))
+}
+
+// GeoMean returns the geometric mean of the positive values.
+func GeoMean(values []float64) float64 {
+	if len(values) == 0 {
+		return math.NaN()
+	}
+	sum := 0.0
+	for _, v := range values {
+		sum += math.Log(v)
+	}
+	return math.Exp(sum / float64(len(values)))
+}
+
+// TQuantile returns the p quantile of the Student's t-distribution with df degrees of freedom.
+func TQuantile(p, df float64) float64 {
+	if p == 0.5 {
+		return 0
+	}
+	if p < 0.5 {
+		return -TQuantile(1-p, df)
+	}
+	// bisection on the cumulative distribution function
+	lo, hi := 0.0, 1.0
+	for TCDF(hi, df) < p {
+		hi *= 2
+	}
+	for i := 0; i < 100; i++ {
+		mid := (lo + hi) / 2
+		if TCDF(mid, df) < p {
+			lo = mid
+		} else {
+			hi = mid
+		}
+	}
+	return (lo + hi) / 2
+}
+
+// TCDF returns the cumulative distribution function of the Student's t-distribution.
+func TCDF(t, df float64) float64 {
+	x := df / (df + t*t)
+	tail := 0.5 * RegIncBeta(df/2, 0.5, x)
+	if t > 0 {
+		return 1 - tail
+	}
+	return tail
+}
+
+// RegIncBeta returns the regularized incomplete beta function I_x(a, b).
+func RegIncBeta(a, b, x float64) float64 {
+	if x <= 0 {
+		return 0
+	}
+	if x >= 1 {
+		return 1
+	}
+	la, _ := math.Lgamma(a)
+	lb, _ := math.Lgamma(b)
+	lab, _ := math.Lgamma(a + b)
+	front := math.Exp(lab - la - lb + a*math.Log(x) + b*math.Log(1-x))
+	if x < (a+1)/(a+b+2) {
+		return front * betaContFrac(a, b, x) / a
+	}
+	return 1 - front*betaContFrac(b, a, 1-x)/b
+}
+
+// betaContFrac evaluates the continued fraction of the incomplete beta function (Lentz's method).
+func betaContFrac(a, b, x float64) float64 {
+	const (
+		tiny = 1e-300
+		eps  = 1e-15
+	)
+	c, d := 1.0, 1-(a+b)*x/(a+1)
+	if math.Abs(d) < tiny {
+		d = tiny
+	}
+	d = 1 / d
+	h := d
+	for m := 1.0; m < 300; m++ {
+		m2 := 2 * m
+		aa := m * (b - m) * x / ((a + m2 - 1) * (a + m2))
+		d = 1 + aa*d
+		if math.Abs(d) < tiny {
+			d = tiny
+		}
+		c = 1 + aa/c
+		if math.Abs(c) < tiny {
+			c = tiny
+		}
+		d = 1 / d
+		h *= d * c
+		aa = -(a + m) * (a + b + m) * x / ((a + m2) * (a + m2 + 1))
+		d = 1 + aa*d
+		if math.Abs(d) < tiny {
+			d = tiny
+		}
+		c = 1 + aa/c
+		if math.Abs(c) < tiny {
+			c = tiny
+		}
+		d = 1 / d
+		del := d * c
+		h *= del
+		if math.Abs(del-1) < eps {
+			break
+		}
+	}
+	return h
+}
+
+// NormalCDF returns the cumulative distribution function of the standard normal distribution.
+func NormalCDF(x float64) float64 {
+	return 0.5 * math.Erfc(-x/math.Sqrt2)
+}
+
+// MannWhitneyU performs a two-sided Mann-Whitney U-test and returns its p-value.
+// Like benchstat, the test makes no assumption about the distribution of the samples.
+// For small samples without ties the exact distribution is used, otherwise the normal
+// approximation with tie correction. The p-value is 1 if one sample is empty.
+func MannWhitneyU(x, y []float64) float64 {
+	n1, n2 := len(x), len(y)
+	if n1 == 0 || n2 == 0 {
+		return 1
+	}
+
+	type obs struct {
+		v     float64
+		first bool
+	}
+	all := make([]obs, 0, n1+n2)
+	for _, v := range x {
+		all = append(all, obs{v, true})
+	}
+	for _, v := range y {
+		all = append(all, obs{v, false})
+	}
+	sort.Slice(all, func(i, j int) bool { return all[i].v < all[j].v })
+
+	// rank sum of x with average ranks for ties
+	r1 := 0.0
+	tieCorr := 0.0
+	ties := false
+	for i := 0; i < len(all); {
+		j := i
+		for j < len(all) && all[j].v == all[i].v {
+			j++
+		}
+		rank := float64(i+j+1) / 2
+		for k := i; k < j; k++ {
+			if all[k].first {
+				r1 += rank
+			}
+		}
+		if t := float64(j - i); t > 1 {
+			ties = true
+			tieCorr += t*t*t - t
+		}
+		i = j
+	}
+	u1 := r1 - float64(n1*(n1+1))/2
+	u := math.Min(u1, float64(n1*n2)-u1)
+
+	if !ties && n1*n2 <= 400 {
+		p := 2 * uCDF(int(u), n1, n2)
+		return math.Min(p, 1)
+	}
+
+	n := float64(n1 + n2)
+	mu := float64(n1*n2) / 2
+	sigma := math.Sqrt(float64(n1*n2) / 12 * ((n + 1) - tieCorr/(n*(n-1))))
+	if sigma == 0 {
+		return 1
+	}
+	// continuity correction
+	z := (u - mu + 0.5) / sigma
+	return math.Min(2*NormalCDF(z), 1)
+}
+
+// uCDF returns P(U <= u) of the exact U distribution without ties.
+func uCDF(u, n1, n2 int) float64 {
+	// counts[i][j][k] is the number of arrangements of i x and j y values with U = k,
+	// it is computed iteratively over i with a two dimensional table.
+	maxU := n1 * n2
+	prev := make([][]float64, n2+1)
+	for j := range prev {
+		prev[j] = make([]float64, maxU+1)
+		prev[j][0] = 1
+	}
+	for i := 1; i <= n1; i++ {
+		cur := make([][]float64, n2+1)
+		for j := range cur {
+			cur[j] = make([]float64, maxU+1)
+		}
+		cur[0][0] = 1
+		for j := 1; j <= n2; j++ {
+			for k := 0; k <= maxU; k++ {
+				// the largest value is either from x (adds j to U) or from y
+				c := cur[j-1][k]
+				if k >= j {
+					c += prev[j][k-j]
+				}
+				cur[j][k] = c
+			}
+		}
+		prev = cur
+	}
+	total, below := 0.0, 0.0
+	for k, c := range prev[n2] {
+		total += c
+		if k <= u {
+			below += c
+		}
+	}
+	return below / total
+}
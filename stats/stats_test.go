@@ -0,0 +1,51 @@
+package stats
+
+import (
+	"math"
+	"testing"
+)
+
+func almostEqual(a, b, eps float64) bool {
+	return math.Abs(a-b) <= eps
+}
+
+func TestTQuantile(t *testing.T) {
+	tests := []struct{ p, df, want float64 }{
+		{0.975, 1, 12.7062},
+		{0.975, 5, 2.5706},
+		{0.975, 30, 2.0423},
+		{0.025, 10, -2.2281},
+	}
+	for _, tt := range tests {
+		if got := TQuantile(tt.p, tt.df); !almostEqual(got, tt.want, 1e-3) {
+			t.Errorf("TQuantile(%v, %v) = %v, want %v", tt.p, tt.df, got, tt.want)
+		}
+	}
+}
+
+func TestSummarize(t *testing.T) {
+	s := Summarize([]float64{2, 4, 4, 4, 5, 5, 7, 9})
+	if s.Mean != 5 || !almostEqual(s.StdDev, 2.1381, 1e-4) || !almostEqual(s.CI, 1.7875, 1e-3) {
+		t.Errorf("unexpected summary: %+v", s)
+	}
+	if got := GeoMean([]float64{1, 4, 16}); !almostEqual(got, 4, 1e-12) {
+		t.Errorf("GeoMean = %v, want 4", got)
+	}
+}
+
+func TestMannWhitneyU(t *testing.T) {
+	tests := []struct {
+		x, y []float64
+		want float64
+	}{
+		{[]float64{1, 2, 3}, []float64{4, 5, 6}, 0.1},
+		{[]float64{1, 2, 3, 4, 5}, []float64{6, 7, 8, 9, 10}, 0.0079},
+		{[]float64{1, 3, 5}, []float64{2, 4, 6}, 0.7},
+		{[]float64{1}, []float64{2}, 1},
+	}
+	for _, tt := range tests {
+		if got := MannWhitneyU(tt.x, tt.y); !almostEqual(got, tt.want, 1e-3) {
+			t.Errorf("MannWhitneyU(%v, %v) = %v, want %v", tt.x, tt.y, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,169 @@
+package trace
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"time"
+)
+
+// Reader reads the events of a trace.
+type Reader struct {
+	r      *bufio.Reader
+	closer io.Closer
+	header Header
+	time   time.Duration
+	buf    []byte
+}
+
+// NewReader reads the header of the trace from r.
+func NewReader(r io.Reader) (*Reader, error) {
+	tr := &Reader{r: bufio.NewReaderSize(r, 64<<10)}
+	var hdr [len(magic) + 4 + 16]byte
+	if _, err := io.ReadFull(tr.r, hdr[:]); err != nil {
+		return nil, fmt.Errorf("invalid trace header: %w", err)
+	}
+	n := len(magic)
+	if string(hdr[:n]) != magic {
+		return nil, fmt.Errorf("invalid trace: magic %q not found", magic)
+	}
+	if hdr[n] != version {
+		return nil, fmt.Errorf("unsupported trace version %d", hdr[n])
+	}
+	tr.header = Header{
+		KeyKind:    Kind(hdr[n+1]),
+		KeySize:    int(hdr[n+2]),
+		Encoding:   Encoding(hdr[n+3]),
+		SampleRate: math.Float64frombits(binary.LittleEndian.Uint64(hdr[n+4:])),
+		Start:      time.Unix(0, int64(binary.LittleEndian.Uint64(hdr[n+12:]))),
+	}
+	if tr.header.KeyKind != KindInt && tr.header.KeyKind != KindString {
+		return nil, fmt.Errorf("invalid trace: unknown key kind %d", tr.header.KeyKind)
+	}
+	if tr.header.Encoding > Anonymized {
+		return nil, fmt.Errorf("invalid trace: unknown encoding %d", tr.header.Encoding)
+	}
+	return tr, nil
+}
+
+// Open opens the trace file at path.
+func Open(path string) (*Reader, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	r, err := NewReader(f)
+	if err != nil {
+		f.Close()
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	r.closer = f
+	return r, nil
+}
+
+// Header returns the header of the trace.
+func (r *Reader) Header() Header {
+	return r.header
+}
+
+// Next returns the next event. At the end of the trace, io.EOF is returned.
+func (r *Reader) Next() (Event, error) {
+	var e Event
+	b, err := r.r.ReadByte()
+	if err != nil {
+		return e, err
+	}
+	e.Op = Op(b & opMask)
+	e.Found = b&flagFound != 0
+	if _, ok := opNames[e.Op]; !ok {
+		return e, fmt.Errorf("invalid trace: unknown operation %d", e.Op)
+	}
+	delta, err := binary.ReadUvarint(r.r)
+	if err != nil {
+		return e, truncated(err)
+	}
+	r.time += time.Duration(delta)
+	e.Time = r.time
+	if !e.Op.hasKey() {
+		return e, nil
+	}
+
+	if r.header.Encoding != Raw {
+		var h [8]byte
+		if _, err := io.ReadFull(r.r, h[:]); err != nil {
+			return e, truncated(err)
+		}
+		e.Int = binary.LittleEndian.Uint64(h[:])
+		e.Len = r.header.KeySize
+		if r.header.KeyKind == KindString {
+			length, err := binary.ReadUvarint(r.r)
+			if err != nil {
+				return e, truncated(err)
+			}
+			e.Len = int(length)
+		}
+		return e, nil
+	}
+
+	switch r.header.KeyKind {
+	case KindInt:
+		e.Int, err = binary.ReadUvarint(r.r)
+		if err != nil {
+			return e, truncated(err)
+		}
+		e.Len = r.header.KeySize
+	case KindString:
+		length, err := binary.ReadUvarint(r.r)
+		if err != nil {
+			return e, truncated(err)
+		}
+		if length > 1<<20 {
+			return e, fmt.Errorf("invalid trace: key length %d", length)
+		}
+		if cap(r.buf) < int(length) {
+			r.buf = make([]byte, length)
+		}
+		buf := r.buf[:length]
+		if _, err := io.ReadFull(r.r, buf); err != nil {
+			return e, truncated(err)
+		}
+		e.Str = string(buf)
+		e.Len = int(length)
+	}
+	return e, nil
+}
+
+// truncated converts an unexpected end of the trace into an error.
+func truncated(err error) error {
+	if errors.Is(err, io.EOF) {
+		return fmt.Errorf("invalid trace: %w", io.ErrUnexpectedEOF)
+	}
+	return err
+}
+
+// ReadAll reads all remaining events.
+func (r *Reader) ReadAll() ([]Event, error) {
+	var events []Event
+	for {
+		e, err := r.Next()
+		if err == io.EOF {
+			return events, nil
+		}
+		if err != nil {
+			return events, err
+		}
+		events = append(events, e)
+	}
+}
+
+// Close closes the trace file, if the reader was created by Open.
+func (r *Reader) Close() error {
+	if r.closer == nil {
+		return nil
+	}
+	return r.closer.Close()
+}
@@ -0,0 +1,132 @@
+package trace
+
+import (
+	"encoding/binary"
+	"sort"
+	"time"
+
+	"github.com/EinfachAndy/hashmaps"
+)
+
+// Replay is a trace prepared for the replay against a map.
+type Replay[K comparable] struct {
+	Ops  []Op
+	Keys []K
+}
+
+// NewReplay converts the events into operations with keys of type K, see IntKey and StringKey.
+func NewReplay[K comparable](events []Event, key func(*Event) K) *Replay[K] {
+	r := &Replay[K]{Ops: make([]Op, len(events)), Keys: make([]K, len(events))}
+	for i := range events {
+		e := &events[i]
+		r.Ops[i] = e.Op
+		if e.Op.hasKey() {
+			r.Keys[i] = key(e)
+		}
+	}
+	return r
+}
+
+// IntKey returns the raw integer key or the hash of the event.
+func IntKey(e *Event) uint64 {
+	return e.Int
+}
+
+// StringKey returns the raw string key of the event. For hashed keys, a string of the original
+// length is synthesized from the hash, so that equal hashes result in equal keys.
+func StringKey(e *Event) string {
+	if e.Str != "" || e.Len == 0 && e.Int == 0 {
+		return e.Str
+	}
+	n := e.Len
+	if n < 8 {
+		n = 8
+	}
+	buf := make([]byte, n)
+	binary.LittleEndian.PutUint64(buf, e.Int)
+	for i := 8; i < n; i++ {
+		buf[i] = 'a' + byte((e.Int>>(i%64))%26)
+	}
+	return string(buf)
+}
+
+func ignore[K comparable, V any](key K, val V) bool {
+	return false
+}
+
+// Run executes all operations against m. Clear is skipped, if m does not support it.
+func (r *Replay[K]) Run(m hashmaps.IHashMap[K, uint64]) {
+	for i, op := range r.Ops {
+		switch op {
+		case OpPut:
+			m.Put(r.Keys[i], 1)
+		case OpGet:
+			m.Get(r.Keys[i])
+		case OpRemove:
+			m.Remove(r.Keys[i])
+		case OpEach:
+			m.Each(ignore[K, uint64])
+		case OpClear:
+			if m.Clear != nil {
+				m.Clear()
+			}
+		}
+	}
+}
+
+// OpStats describes the latency of one operation type.
+type OpStats struct {
+	Count int
+	Mean  time.Duration
+	P50   time.Duration
+	P99   time.Duration
+}
+
+// Profile is the result of a replay with measured latencies.
+type Profile struct {
+	// Found contains the results of all Get operations in order.
+	Found []bool
+	// Stats contains the latencies by operation.
+	Stats map[Op]OpStats
+}
+
+// Profile executes all operations against m like Run, but measures the latency of each
+// operation and records the results of the Get operations. The timing adds a constant
+// overhead to each operation, so the latencies are only comparable between maps.
+func (r *Replay[K]) Profile(m hashmaps.IHashMap[K, uint64]) Profile {
+	p := Profile{Stats: make(map[Op]OpStats)}
+	latencies := make(map[Op][]time.Duration)
+	for i, op := range r.Ops {
+		start := time.Now()
+		switch op {
+		case OpPut:
+			m.Put(r.Keys[i], 1)
+		case OpGet:
+			_, found := m.Get(r.Keys[i])
+			p.Found = append(p.Found, found)
+		case OpRemove:
+			m.Remove(r.Keys[i])
+		case OpEach:
+			m.Each(ignore[K, uint64])
+		case OpClear:
+			if m.Clear != nil {
+				m.Clear()
+			}
+		}
+		latencies[op] = append(latencies[op], time.Since(start))
+	}
+	for op, values := range latencies {
+		sort.Slice(values, func(i, j int) bool { return values[i] < values[j] })
+		var total time.Duration
+		for _, v := range values {
+			total += v
+		}
+		p.Stats[op] = OpStats{
+			Count: len(values),
+			Mean:  total / time.Duration(len(values)),
+			P50:   values[len(values)/2],
+			P99:   values[len(values)*99/100],
+		}
+	}
+	return p
+}
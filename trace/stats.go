@@ -0,0 +1,365 @@
+package trace
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"time"
+)
+
+// Bucket is a bin of a histogram with the values in [Min, Max].
+type Bucket struct {
+	Min   int `json:"min"`
+	Max   int `json:"max"`
+	Count int `json:"count"`
+}
+
+// Window describes a part of the trace with the same number of events.
+type Window struct {
+	Start  time.Duration  `json:"start"`
+	End    time.Duration  `json:"end"`
+	Events int            `json:"events"`
+	Ops    map[string]int `json:"ops"`
+	// WorkingSet is the number of distinct keys accessed in the window.
+	WorkingSet int `json:"workingSet"`
+	// LiveKeys is the number of keys in the map at the end of the window.
+	LiveKeys int `json:"liveKeys"`
+}
+
+// ZipfFit is the fit of the key popularity to a zipf distribution, frequency ~ rank^-S.
+type ZipfFit struct {
+	S float64 `json:"s"`
+	// R2 is the coefficient of determination of the fit on the log-log scale.
+	R2 float64 `json:"r2"`
+	// Ranks is the number of fitted ranks.
+	Ranks int `json:"ranks"`
+}
+
+// Stats characterizes a trace.
+type Stats struct {
+	KeyKind    string        `json:"keyKind"`
+	KeySize    int           `json:"keySize,omitempty"`
+	Encoding   string        `json:"encoding"`
+	SampleRate float64       `json:"sampleRate"`
+	Events     int           `json:"events"`
+	Duration   time.Duration `json:"duration"`
+	// Ops are the number of operations by name.
+	Ops map[string]int `json:"ops"`
+	// Cold are the number of first accesses of a key by operation.
+	Cold map[string]int `json:"cold"`
+	// HitRate is the ratio of successful gets.
+	HitRate      float64 `json:"hitRate"`
+	DistinctKeys int     `json:"distinctKeys"`
+	// Inserts are puts of keys, which are not in the map, Updates puts of existing keys.
+	Inserts int `json:"inserts"`
+	Updates int `json:"updates"`
+	// Deletes are removes of existing keys.
+	Deletes int `json:"deletes"`
+	// InsertRate and DeleteRate are the inserts and deletes per second.
+	InsertRate float64  `json:"insertRate"`
+	DeleteRate float64  `json:"deleteRate"`
+	Windows    []Window `json:"windows"`
+	// Reuse is the histogram of the reuse distances, the number of distinct keys accessed
+	// between two accesses of the same key. First accesses are counted in Cold.
+	Reuse []Bucket `json:"reuse"`
+	// KeyLengths is the histogram of the distinct key lengths in bytes.
+	KeyLengths []Bucket `json:"keyLengths"`
+	Zipf       ZipfFit  `json:"zipf"`
+}
+
+// Validate checks the windows of statistics, which are not computed by Characterize, e.g. read from JSON.
+// Each window needs events and its operation counts must sum up to them.
+func (s *Stats) Validate() error {
+	if s.Events == 0 || len(s.Windows) == 0 {
+		return fmt.Errorf("empty trace statistics")
+	}
+	for i := range s.Windows {
+		win := &s.Windows[i]
+		if win.Events <= 0 {
+			return fmt.Errorf("window %d: no events", i)
+		}
+		sum := 0
+		for name, c := range win.Ops {
+			if !knownOp(name) || c < 0 {
+				return fmt.Errorf("window %d: invalid operation %q with count %d", i, name, c)
+			}
+			sum += c
+		}
+		if sum != win.Events {
+			return fmt.Errorf("window %d: %d operations, but %d events", i, sum, win.Events)
+		}
+	}
+	return nil
+}
+
+func knownOp(name string) bool {
+	for _, n := range opNames {
+		if n == name {
+			return true
+		}
+	}
+	return false
+}
+
+// fenwick is a binary indexed tree of counts.
+type fenwick []int
+
+func (f fenwick) add(i, v int) {
+	for i++; i < len(f); i += i & -i {
+		f[i] += v
+	}
+}
+
+// sum returns the sum of [0, i].
+func (f fenwick) sum(i int) int {
+	s := 0
+	for i++; i > 0; i -= i & -i {
+		s += f[i]
+	}
+	return s
+}
+
+// find returns the smallest index, whose prefix sum is k, 1 <= k <= sum of all.
+func (f fenwick) find(k int) int {
+	pos := 0
+	step := 1
+	for step*2 < len(f) {
+		step *= 2
+	}
+	for ; step > 0; step /= 2 {
+		if pos+step < len(f) && f[pos+step] < k {
+			pos += step
+			k -= f[pos]
+		}
+	}
+	return pos
+}
+
+// keyIDs assigns consecutive ids to the keys of the events.
+type keyIDs struct {
+	ints    map[uint64]int
+	strings map[string]int
+}
+
+func newKeyIDs() *keyIDs {
+	return &keyIDs{ints: make(map[uint64]int), strings: make(map[string]int)}
+}
+
+func (k *keyIDs) len() int {
+	return len(k.ints) + len(k.strings)
+}
+
+// id returns the id of the key and whether it is new.
+func (k *keyIDs) id(e *Event) (int, bool) {
+	if e.Str != "" {
+		id, ok := k.strings[e.Str]
+		if !ok {
+			id = k.len()
+			k.strings[e.Str] = id
+		}
+		return id, !ok
+	}
+	id, ok := k.ints[e.Int]
+	if !ok {
+		id = k.len()
+		k.ints[e.Int] = id
+	}
+	return id, !ok
+}
+
+// bucketIndex returns the index of the power of two bucket of v: [0], [1], [2,3], [4,7], ...
+func bucketIndex(v int) int {
+	i := 0
+	for v > 0 {
+		v >>= 1
+		i++
+	}
+	return i
+}
+
+func bucketRange(i int) (int, int) {
+	if i == 0 {
+		return 0, 0
+	}
+	return 1 << (i - 1), 1<<i - 1
+}
+
+func addBucket(buckets []Bucket, v int) []Bucket {
+	i := bucketIndex(v)
+	for len(buckets) <= i {
+		min, max := bucketRange(len(buckets))
+		buckets = append(buckets, Bucket{Min: min, Max: max})
+	}
+	buckets[i].Count++
+	return buckets
+}
+
+// Characterize reads all events of r and computes the statistics of the trace.
+// The trace is split into the given number of windows with the same number of events.
+func Characterize(r *Reader, windows int) (*Stats, error) {
+	events, err := r.ReadAll()
+	if err != nil {
+		return nil, err
+	}
+	h := r.Header()
+	s := &Stats{
+		KeyKind:    h.KeyKind.String(),
+		KeySize:    h.KeySize,
+		Encoding:   h.Encoding.String(),
+		SampleRate: h.SampleRate,
+		Events:     len(events),
+		Ops:        make(map[string]int),
+		Cold:       make(map[string]int),
+	}
+	if len(events) == 0 {
+		return s, nil
+	}
+	s.Duration = events[len(events)-1].Time
+	if windows < 1 {
+		windows = 1
+	}
+	if windows > len(events) {
+		windows = len(events)
+	}
+
+	ids := newKeyIDs()
+	var (
+		last      []int // last access of a key by id, -1 if not accessed
+		live      []bool
+		liveCount int
+		counts    []int
+		tree      = make(fenwick, len(events)+1)
+		gets      int
+		hits      int
+	)
+	var win *Window
+	lengths := make(map[int]int)
+	seen := make(map[int]int) // key id -> window index of the last access
+	for i := range events {
+		e := &events[i]
+		w := i * windows / len(events)
+		if w >= len(s.Windows) {
+			s.Windows = append(s.Windows, Window{Start: e.Time, Ops: make(map[string]int)})
+			win = &s.Windows[w]
+		}
+		win.End = e.Time
+		win.Events++
+		op := e.Op.String()
+		s.Ops[op]++
+		win.Ops[op]++
+
+		switch e.Op {
+		case OpClear:
+			for id := range live {
+				live[id] = false
+			}
+			liveCount = 0
+		case OpPut, OpGet, OpRemove:
+			id, isNew := ids.id(e)
+			if isNew {
+				last = append(last, -1)
+				live = append(live, false)
+				counts = append(counts, 0)
+				s.Cold[op]++
+				lengths[e.Len]++
+			} else {
+				// distinct keys accessed since the last access of this key
+				distance := tree.sum(i-1) - tree.sum(last[id])
+				s.Reuse = addBucket(s.Reuse, distance)
+				tree.add(last[id], -1)
+			}
+			tree.add(i, 1)
+			last[id] = i
+			counts[id]++
+			if wi, ok := seen[id]; !ok || wi != w {
+				seen[id] = w
+				win.WorkingSet++
+			}
+
+			switch e.Op {
+			case OpGet:
+				gets++
+				if e.Found {
+					hits++
+				}
+			case OpPut:
+				if live[id] {
+					s.Updates++
+				} else {
+					s.Inserts++
+					live[id] = true
+					liveCount++
+				}
+			case OpRemove:
+				if live[id] {
+					s.Deletes++
+					live[id] = false
+					liveCount--
+				}
+			}
+		}
+		win.LiveKeys = liveCount
+	}
+
+	s.DistinctKeys = ids.len()
+	for length, count := range lengths {
+		s.KeyLengths = append(s.KeyLengths, Bucket{Min: length, Max: length, Count: count})
+	}
+	sort.Slice(s.KeyLengths, func(i, j int) bool { return s.KeyLengths[i].Min < s.KeyLengths[j].Min })
+	if gets > 0 {
+		s.HitRate = float64(hits) / float64(gets)
+	}
+	if sec := s.Duration.Seconds(); sec > 0 {
+		s.InsertRate = float64(s.Inserts) / sec
+		s.DeleteRate = float64(s.Deletes) / sec
+	}
+	s.Zipf = fitZipf(counts)
+	return s, nil
+}
+
+// maxZipfRanks limits the fitted ranks, the tail of rarely accessed keys is dominated by the sampling noise.
+const maxZipfRanks = 10000
+
+// fitZipf fits log(frequency) = c - s*log(rank) by least squares.
+func fitZipf(counts []int) ZipfFit {
+	sorted := append([]int(nil), counts...)
+	sort.Sort(sort.Reverse(sort.IntSlice(sorted)))
+	if len(sorted) > maxZipfRanks {
+		sorted = sorted[:maxZipfRanks]
+	}
+	n := float64(len(sorted))
+	if n < 2 {
+		return ZipfFit{Ranks: len(sorted)}
+	}
+	var sx, sy, sxx, sxy, syy float64
+	for i, c := range sorted {
+		x := math.Log(float64(i + 1))
+		y := math.Log(float64(c))
+		sx += x
+		sy += y
+		sxx += x * x
+		sxy += x * y
+		syy += y * y
+	}
+	varX := sxx - sx*sx/n
+	varY := syy - sy*sy/n
+	cov := sxy - sx*sy/n
+	fit := ZipfFit{Ranks: len(sorted)}
+	if varX > 0 {
+		fit.S = -cov / varX
+	}
+	if varX > 0 && varY > 0 {
+		fit.R2 = cov * cov / (varX * varY)
+	}
+	return fit
+}
+
+// ReadStats is a shortcut for Characterize of a trace file.
+func ReadStats(path string, windows int) (*Stats, error) {
+	r, err := Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+	return Characterize(r, windows)
+}
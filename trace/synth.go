@@ -0,0 +1,184 @@
+package trace
+
+import (
+	"fmt"
+	"io"
+	"math/rand"
+	"time"
+)
+
+// synthesizer generates the keys of a synthetic trace by an LRU stack model: a key is either new
+// or the key at a reuse distance drawn from the histogram of the original trace.
+type synthesizer struct {
+	s    *Stats
+	rng  *rand.Rand
+	tree fenwick
+	// keys and lengths by position of the last access
+	keys    []uint64
+	lengths []int
+	live    map[uint64]bool
+	// reuse is the cumulative histogram of the reuse distances
+	reuse []int
+}
+
+func (sy *synthesizer) newKey() (uint64, int) {
+	key := sy.rng.Uint64()
+	if sy.s.KeyKind == KindInt.String() && sy.s.KeySize > 0 && sy.s.KeySize < 8 {
+		key &= 1<<(8*sy.s.KeySize) - 1
+	}
+	return key, sy.length()
+}
+
+// length draws a key length from the histogram of the original trace.
+func (sy *synthesizer) length() int {
+	if sy.s.KeyKind == KindInt.String() {
+		return sy.s.KeySize
+	}
+	total := 0
+	for _, b := range sy.s.KeyLengths {
+		total += b.Count
+	}
+	if total == 0 {
+		return 16
+	}
+	x := sy.rng.Intn(total)
+	for _, b := range sy.s.KeyLengths {
+		if x < b.Count {
+			return b.Min + sy.rng.Intn(b.Max-b.Min+1)
+		}
+		x -= b.Count
+	}
+	return 16
+}
+
+// reuseKey returns the position of a previously accessed key, drawn from the reuse distances.
+func (sy *synthesizer) reuseKey(distinct int) int {
+	total := sy.reuse[len(sy.reuse)-1]
+	x := sy.rng.Intn(total)
+	i := 0
+	for sy.reuse[i] <= x {
+		i++
+	}
+	min, max := bucketRange(i)
+	if max > distinct-1 {
+		max = distinct - 1
+	}
+	if min > max {
+		min = max
+	}
+	distance := min + sy.rng.Intn(max-min+1)
+	// the most recently accessed key has the distance 0
+	return sy.tree.find(distinct - distance)
+}
+
+// Synthesize writes a synthetic trace with n events to w, whose statistics match s:
+// the operation mix and timing of each window, the first access ratios,
+// the reuse distances and the key lengths. The keys are random, integer keys are
+// written raw and string keys as hashes with synthetic lengths. Like Writer.Close,
+// out is closed, if it is an io.Closer.
+func Synthesize(s *Stats, out io.Writer, n int, rng *rand.Rand) error {
+	if err := s.Validate(); err != nil {
+		return err
+	}
+	h := Header{KeyKind: KindInt, KeySize: s.KeySize, Encoding: Raw, SampleRate: 1}
+	if s.KeyKind == KindString.String() {
+		h.KeyKind = KindString
+		h.KeySize = 0
+		h.Encoding = Hashed
+	}
+	w, err := NewWriter(out, h)
+	if err != nil {
+		return err
+	}
+	clock := w.header.Start
+	w.now = func() time.Time { return clock }
+
+	sy := &synthesizer{
+		s:       s,
+		rng:     rng,
+		tree:    make(fenwick, n+1),
+		keys:    make([]uint64, n),
+		lengths: make([]int, n),
+		live:    make(map[uint64]bool),
+	}
+	sum := 0
+	for _, b := range s.Reuse {
+		sum += b.Count
+		sy.reuse = append(sy.reuse, sum)
+	}
+	lastPos := make(map[uint64]int)
+	distinct := 0
+
+	for i := 0; i < n; i++ {
+		win := &s.Windows[i*len(s.Windows)/n]
+		// the operation is drawn from the mix of the window
+		x := rng.Intn(win.Events)
+		var op Op
+		for o := OpPut; o <= OpClear; o++ {
+			c := win.Ops[o.String()]
+			if x < c {
+				op = o
+				break
+			}
+			x -= c
+		}
+		mean := float64(win.End-win.Start) / float64(win.Events)
+		clock = clock.Add(time.Duration(rng.ExpFloat64() * mean))
+
+		switch op {
+		case OpEach:
+			w.WriteOp(op)
+			continue
+		case OpClear:
+			sy.live = make(map[uint64]bool)
+			w.WriteOp(op)
+			continue
+		}
+
+		var (
+			key    uint64
+			length int
+		)
+		cold := float64(s.Cold[op.String()]) / float64(s.Ops[op.String()])
+		isNew := distinct == 0 || len(sy.reuse) == 0 || rng.Float64() < cold
+		if isNew {
+			// small integer keys may run out of new keys
+			isNew = false
+			for attempt := 0; attempt < 64 && !isNew; attempt++ {
+				key, length = sy.newKey()
+				_, seen := lastPos[key]
+				isNew = !seen
+			}
+			if !isNew && distinct == 0 {
+				return fmt.Errorf("no new key found")
+			}
+		}
+		if isNew {
+			distinct++
+		} else if len(sy.reuse) == 0 {
+			pos := lastPos[key]
+			sy.tree.add(pos, -1)
+		} else {
+			pos := sy.reuseKey(distinct)
+			key, length = sy.keys[pos], sy.lengths[pos]
+			sy.tree.add(pos, -1)
+		}
+		sy.tree.add(i, 1)
+		sy.keys[i], sy.lengths[i] = key, length
+		lastPos[key] = i
+
+		found := sy.live[key]
+		switch op {
+		case OpPut:
+			sy.live[key] = true
+		case OpRemove:
+			delete(sy.live, key)
+		}
+		if h.Encoding == Raw {
+			w.WriteInt(op, op == OpGet && found, key)
+		} else {
+			w.WriteHash(op, op == OpGet && found, key, length)
+		}
+	}
+	return w.Close()
+}
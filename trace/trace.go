@@ -0,0 +1,137 @@
+// Package trace records the operations on a hashmaps.IHashMap into a compact binary trace file,
+// which can be replayed by the benchmarks to reproduce real access patterns.
+//
+// A trace starts with a header:
+//
+//	magic "HMTR", version, key kind, key size, encoding, sample rate (float64), start (unix ns)
+//
+// followed by one record per operation:
+//
+//	op | flags, time delta since the previous record (uvarint ns), key
+//
+// The encoding of the key depends on the header. Raw integer keys are stored as uvarint, raw string
+// keys as uvarint length and bytes. Hashed and anonymized keys are stored as 8 byte hash, for string
+// keys followed by the uvarint length of the original key. Each and Clear have no key.
+package trace
+
+import (
+	"fmt"
+	"time"
+)
+
+// Op is a recorded map operation.
+type Op uint8
+
+// Recorded operations.
+const (
+	OpPut Op = iota + 1
+	OpGet
+	OpRemove
+	OpEach
+	OpClear
+)
+
+var opNames = map[Op]string{OpPut: "put", OpGet: "get", OpRemove: "remove", OpEach: "each", OpClear: "clear"}
+
+func (op Op) String() string {
+	if name, ok := opNames[op]; ok {
+		return name
+	}
+	return fmt.Sprintf("op(%d)", uint8(op))
+}
+
+// hasKey reports whether the operation stores a key.
+func (op Op) hasKey() bool {
+	return op == OpPut || op == OpGet || op == OpRemove
+}
+
+const (
+	opMask    = 0x0f
+	flagFound = 0x80
+)
+
+// Kind is the kind of the recorded keys.
+type Kind uint8
+
+// Key kinds.
+const (
+	KindInt Kind = iota + 1
+	KindString
+)
+
+func (k Kind) String() string {
+	switch k {
+	case KindInt:
+		return "int"
+	case KindString:
+		return "string"
+	}
+	return fmt.Sprintf("kind(%d)", uint8(k))
+}
+
+// Encoding defines how the keys are stored.
+type Encoding uint8
+
+// Key encodings.
+const (
+	// Raw stores the original keys.
+	Raw Encoding = iota
+	// Hashed stores a deterministic 64 bit hash of the keys, equal keys have the same hash in all traces.
+	Hashed
+	// Anonymized stores a 64 bit hash with a random secret seed, which is not stored in the trace.
+	// Equal keys have the same hash only within one trace.
+	Anonymized
+)
+
+func (e Encoding) String() string {
+	switch e {
+	case Raw:
+		return "raw"
+	case Hashed:
+		return "hashed"
+	case Anonymized:
+		return "anonymized"
+	}
+	return fmt.Sprintf("encoding(%d)", uint8(e))
+}
+
+// ParseEncoding parses the name of an encoding.
+func ParseEncoding(s string) (Encoding, error) {
+	for _, e := range []Encoding{Raw, Hashed, Anonymized} {
+		if e.String() == s {
+			return e, nil
+		}
+	}
+	return 0, fmt.Errorf("unknown encoding %q, available: raw hashed anonymized", s)
+}
+
+// Header describes a trace.
+type Header struct {
+	KeyKind Kind
+	// KeySize is the size of integer keys in bytes, 0 for strings.
+	KeySize  int
+	Encoding Encoding
+	// SampleRate is the ratio of the recorded keys, 1 if all operations are recorded.
+	SampleRate float64
+	Start      time.Time
+}
+
+// Event is a single recorded operation.
+type Event struct {
+	Op Op
+	// Found is the result of a Get operation.
+	Found bool
+	// Time is the time since the start of the trace.
+	Time time.Duration
+	// Int is the raw integer key or the hash of the key.
+	Int uint64
+	// Str is the raw string key.
+	Str string
+	// Len is the length of the original key in bytes.
+	Len int
+}
+
+const (
+	magic   = "HMTR"
+	version = 1
+)
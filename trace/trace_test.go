@@ -0,0 +1,254 @@
+package trace
+
+import (
+	"bytes"
+	"io"
+	"math/rand"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/EinfachAndy/hashmaps"
+)
+
+func stdMap[K comparable]() hashmaps.IHashMap[K, int] {
+	m := make(map[K]int)
+	return hashmaps.IHashMap[K, int]{
+		Put: func(k K, v int) bool {
+			m[k] = v
+			return true
+		},
+		Get: func(k K) (int, bool) {
+			v, ok := m[k]
+			return v, ok
+		},
+		Remove: func(k K) bool {
+			delete(m, k)
+			return true
+		},
+		Each: func(fn func(k K, v int) bool) {
+			for k, v := range m {
+				if fn(k, v) {
+					return
+				}
+			}
+		},
+	}
+}
+
+func TestRoundTripInt(t *testing.T) {
+	var buf bytes.Buffer
+	m, w, err := Wrap(stdMap[uint32](), &buf, Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	m.Put(42, 1)
+	m.Get(42)
+	m.Get(7)
+	m.Each(func(k uint32, v int) bool { return false })
+	m.Remove(42)
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	r, err := NewReader(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if h := r.Header(); h.KeyKind != KindInt || h.KeySize != 4 || h.Encoding != Raw || h.SampleRate != 1 {
+		t.Fatalf("unexpected header %+v", h)
+	}
+	events, err := r.ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []Event{
+		{Op: OpPut, Int: 42, Len: 4},
+		{Op: OpGet, Found: true, Int: 42, Len: 4},
+		{Op: OpGet, Int: 7, Len: 4},
+		{Op: OpEach},
+		{Op: OpRemove, Int: 42, Len: 4},
+	}
+	if len(events) != len(want) {
+		t.Fatalf("got %d events, want %d", len(events), len(want))
+	}
+	for i, e := range events {
+		if i > 0 && e.Time < events[i-1].Time {
+			t.Errorf("event %d: time not monotonic", i)
+		}
+		e.Time = 0
+		if e != want[i] {
+			t.Errorf("event %d = %+v, want %+v", i, e, want[i])
+		}
+	}
+}
+
+func TestEncodings(t *testing.T) {
+	for _, enc := range []Encoding{Raw, Hashed, Anonymized} {
+		var buf bytes.Buffer
+		m, w, err := Wrap(stdMap[string](), &buf, Options{Encoding: enc})
+		if err != nil {
+			t.Fatal(err)
+		}
+		m.Put("user-1234", 1)
+		m.Get("user-1234")
+		if err := w.Close(); err != nil {
+			t.Fatal(err)
+		}
+		r, err := NewReader(&buf)
+		if err != nil {
+			t.Fatal(err)
+		}
+		events, err := r.ReadAll()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(events) != 2 || events[0].Len != 9 || events[0].Int != events[1].Int || events[0].Str != events[1].Str {
+			t.Fatalf("%s: unexpected events %+v", enc, events)
+		}
+		if enc == Raw && events[0].Str != "user-1234" {
+			t.Errorf("raw key = %q", events[0].Str)
+		}
+		if enc != Raw && (events[0].Str != "" || bytes.Contains(buf.Bytes(), []byte("user-1234"))) {
+			t.Errorf("%s: key is not hidden", enc)
+		}
+		if enc == Hashed && events[0].Int != HashString("user-1234") {
+			t.Errorf("hashed key = %x", events[0].Int)
+		}
+	}
+}
+
+func TestSampleThreshold(t *testing.T) {
+	tests := []struct {
+		rate      float64
+		threshold uint64
+		sampled   bool
+	}{
+		{0, 0, false},
+		{1, 0, false},
+		{0.5, 1 << 63, true},
+		{0.25, 1 << 62, true},
+		{1 - 1.0/(1<<53), 1<<64 - 1<<11, true},
+		{1.0 / (1 << 53), 1 << 11, true},
+	}
+	for _, tt := range tests {
+		threshold, sampled := sampleThreshold(tt.rate)
+		if threshold != tt.threshold || sampled != tt.sampled {
+			t.Errorf("sampleThreshold(%g) = %#x, %v, want %#x, %v", tt.rate, threshold, sampled, tt.threshold, tt.sampled)
+		}
+	}
+}
+
+func TestSampling(t *testing.T) {
+	var buf bytes.Buffer
+	m, w, err := Wrap(stdMap[string](), &buf, Options{SampleRate: 0.1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	const n = 10000
+	for i := 0; i < n; i++ {
+		m.Put(strconv.Itoa(i), i)
+		m.Get(strconv.Itoa(i))
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	r, err := NewReader(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	events, err := r.ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(events) < 2*n/20 || len(events) > 2*n/5 {
+		t.Errorf("got %d events, expected about %d", len(events), 2*n/10)
+	}
+	// all operations of a sampled key are recorded
+	for i := 0; i+1 < len(events); i += 2 {
+		if events[i].Op != OpPut || events[i+1].Op != OpGet || events[i].Str != events[i+1].Str {
+			t.Fatalf("sampling split the operations of a key: %+v %+v", events[i], events[i+1])
+		}
+	}
+}
+
+func TestCharacterize(t *testing.T) {
+	var buf bytes.Buffer
+	m, w, err := Wrap(stdMap[uint64](), &buf, Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	m.Put(1, 1)
+	m.Get(1)
+	m.Get(2)
+	m.Get(1)
+	m.Remove(1)
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	r, err := NewReader(bytes.NewReader(buf.Bytes()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	s, err := Characterize(r, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s.Events != 5 || s.DistinctKeys != 2 || s.Inserts != 1 || s.Deletes != 1 || s.Cold["get"] != 1 {
+		t.Errorf("unexpected stats %+v", s)
+	}
+	if s.HitRate < 0.66 || s.HitRate > 0.67 {
+		t.Errorf("hit rate = %f, want 2/3", s.HitRate)
+	}
+	// get 1 directly after put 1, get 1 after get 2, remove 1 directly after get 1
+	want := []Bucket{{0, 0, 2}, {1, 1, 1}}
+	if !reflect.DeepEqual(s.Reuse, want) {
+		t.Errorf("reuse = %v, want %v", s.Reuse, want)
+	}
+	if len(s.Windows) != 2 || s.Windows[0].Events+s.Windows[1].Events != 5 {
+		t.Errorf("unexpected windows %+v", s.Windows)
+	}
+
+	var synth bytes.Buffer
+	if err := Synthesize(s, &synth, 1000, rand.New(rand.NewSource(1))); err != nil {
+		t.Fatal(err)
+	}
+	r, err = NewReader(&synth)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s2, err := Characterize(r, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s2.Events != 1000 || s2.Ops["get"] < 500 || s2.Ops["get"] > 700 {
+		t.Errorf("synthetic ops = %v", s2.Ops)
+	}
+}
+
+func TestSynthesizeInvalidStats(t *testing.T) {
+	window := func(events int, ops map[string]int) []Window {
+		return []Window{{Start: 0, End: time.Second, Events: events, Ops: ops}}
+	}
+	tests := []struct {
+		name    string
+		windows []Window
+		want    string
+	}{
+		{"no windows", nil, "empty trace statistics"},
+		{"empty window", window(0, map[string]int{}), "no events"},
+		{"too few operations", window(10, map[string]int{"get": 6, "put": 3}), "9 operations, but 10 events"},
+		{"too many operations", window(10, map[string]int{"get": 8, "put": 3}), "11 operations, but 10 events"},
+		{"unknown operation", window(10, map[string]int{"get": 5, "scan": 5}), `invalid operation "scan"`},
+		{"negative count", window(10, map[string]int{"get": 11, "put": -1}), `invalid operation "put"`},
+	}
+	for _, tt := range tests {
+		s := &Stats{KeyKind: KindInt.String(), KeySize: 8, Events: 10, Ops: map[string]int{"get": 10}, Windows: tt.windows}
+		err := Synthesize(s, io.Discard, 100, rand.New(rand.NewSource(1)))
+		if err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("%s: error = %v, want %q", tt.name, err, tt.want)
+		}
+	}
+}
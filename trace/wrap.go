@@ -0,0 +1,201 @@
+package trace
+
+import (
+	"fmt"
+	"hash/maphash"
+	"io"
+	"reflect"
+	"unsafe"
+
+	"github.com/EinfachAndy/hashmaps"
+)
+
+// Options configures the recording of a map.
+type Options struct {
+	// Encoding defines how the keys are stored, see Raw, Hashed and Anonymized.
+	Encoding Encoding
+	// SampleRate is the ratio of the recorded keys in (0, 1], all keys are recorded if it is 0.
+	// The sampling is done per key, so that all operations on a sampled key are recorded
+	// and the reuse of keys is preserved.
+	SampleRate float64
+}
+
+// recorder encodes and samples the keys of type K.
+type recorder[K comparable] struct {
+	w         *Writer
+	kind      Kind
+	size      int
+	encoding  Encoding
+	threshold uint64
+	sampled   bool
+	seed      maphash.Seed
+	toInt     func(K) uint64
+	toString  func(K) string
+}
+
+// mix64 is the finalizer of splitmix64, a fast bijective mixer of 64 bit integers.
+func mix64(x uint64) uint64 {
+	x ^= x >> 30
+	x *= 0xbf58476d1ce4e5b9
+	x ^= x >> 27
+	x *= 0x94d049bb133111eb
+	x ^= x >> 31
+	return x
+}
+
+// fnv1a is the 64 bit FNV-1a hash of s.
+func fnv1a(s string) uint64 {
+	h := uint64(14695981039346656037)
+	for i := 0; i < len(s); i++ {
+		h ^= uint64(s[i])
+		h *= 1099511628211
+	}
+	return h
+}
+
+// HashInt is the deterministic hash of integer keys used by the Hashed encoding.
+func HashInt(x uint64) uint64 {
+	return mix64(x)
+}
+
+// HashString is the deterministic hash of string keys used by the Hashed encoding.
+func HashString(s string) uint64 {
+	return mix64(fnv1a(s))
+}
+
+func newRecorder[K comparable](opts Options) (*recorder[K], error) {
+	rec := &recorder[K]{encoding: opts.Encoding, seed: maphash.MakeSeed()}
+	var key K
+	kind := reflect.TypeOf(&key).Elem().Kind()
+	if kind == reflect.Int || kind == reflect.Uint || kind == reflect.Uintptr {
+		// platform dependent sizes
+		if unsafe.Sizeof(key) == 4 {
+			kind = reflect.Uint32
+		} else {
+			kind = reflect.Uint64
+		}
+	}
+	switch kind {
+	case reflect.Int64, reflect.Uint64:
+		rec.toInt = func(k K) uint64 { return *(*uint64)(unsafe.Pointer(&k)) }
+	case reflect.Int32, reflect.Uint32:
+		rec.toInt = func(k K) uint64 { return uint64(*(*uint32)(unsafe.Pointer(&k))) }
+	case reflect.Int16, reflect.Uint16:
+		rec.toInt = func(k K) uint64 { return uint64(*(*uint16)(unsafe.Pointer(&k))) }
+	case reflect.Int8, reflect.Uint8:
+		rec.toInt = func(k K) uint64 { return uint64(*(*uint8)(unsafe.Pointer(&k))) }
+	case reflect.String:
+		rec.toString = func(k K) string { return *(*string)(unsafe.Pointer(&k)) }
+	default:
+		return nil, fmt.Errorf("unsupported key type %T", key)
+	}
+	if rec.toInt != nil {
+		rec.kind = KindInt
+		rec.size = int(unsafe.Sizeof(key))
+	} else {
+		rec.kind = KindString
+	}
+	if opts.Encoding > Anonymized {
+		return nil, fmt.Errorf("unknown encoding %d", opts.Encoding)
+	}
+	if opts.SampleRate < 0 || opts.SampleRate > 1 {
+		return nil, fmt.Errorf("invalid sample rate %g, expected a value in (0, 1]", opts.SampleRate)
+	}
+	rec.threshold, rec.sampled = sampleThreshold(opts.SampleRate)
+	return rec, nil
+}
+
+// sampleThreshold returns the hash threshold of the sampled keys. The rates 0 and 1 record all keys.
+// The threshold is scaled from the 53 bit mantissa, because converting a float64 near 2^64 to uint64
+// overflows.
+func sampleThreshold(rate float64) (threshold uint64, sampled bool) {
+	if rate <= 0 || rate >= 1 {
+		return 0, false
+	}
+	return uint64(rate*(1<<53)) << 11, true
+}
+
+// hash returns the deterministic hash of the key.
+func (r *recorder[K]) hash(k K) uint64 {
+	if r.toInt != nil {
+		return HashInt(r.toInt(k))
+	}
+	return HashString(r.toString(k))
+}
+
+// record writes the operation, if the key is sampled.
+func (r *recorder[K]) record(op Op, found bool, k K) {
+	var h uint64
+	if r.sampled || r.encoding == Hashed {
+		h = r.hash(k)
+		if r.sampled && h >= r.threshold {
+			return
+		}
+	}
+	switch r.encoding {
+	case Raw:
+		if r.toInt != nil {
+			r.w.WriteInt(op, found, r.toInt(k))
+		} else {
+			r.w.WriteString(op, found, r.toString(k))
+		}
+	case Hashed:
+		r.w.WriteHash(op, found, h, r.length(k))
+	case Anonymized:
+		if r.toInt != nil {
+			x := r.toInt(k)
+			r.w.WriteHash(op, found, maphash.Bytes(r.seed, unsafe.Slice((*byte)(unsafe.Pointer(&x)), 8)), r.size)
+		} else {
+			s := r.toString(k)
+			r.w.WriteHash(op, found, maphash.String(r.seed, s), len(s))
+		}
+	}
+}
+
+func (r *recorder[K]) length(k K) int {
+	if r.toString != nil {
+		return len(r.toString(k))
+	}
+	return r.size
+}
+
+// Wrap returns a map, which records all Put, Get, Remove, Each and Clear calls on m into a trace
+// written to w. The trace must be closed with the returned writer to flush the buffered records.
+func Wrap[K comparable, V any](m hashmaps.IHashMap[K, V], w io.Writer, opts Options) (hashmaps.IHashMap[K, V], *Writer, error) {
+	rec, err := newRecorder[K](opts)
+	if err != nil {
+		return m, nil, err
+	}
+	rec.w, err = NewWriter(w, Header{KeyKind: rec.kind, KeySize: rec.size, Encoding: opts.Encoding, SampleRate: opts.SampleRate})
+	if err != nil {
+		return m, nil, err
+	}
+
+	wrapped := m
+	wrapped.Put = func(k K, v V) bool {
+		rec.record(OpPut, false, k)
+		return m.Put(k, v)
+	}
+	wrapped.Get = func(k K) (V, bool) {
+		v, found := m.Get(k)
+		rec.record(OpGet, found, k)
+		return v, found
+	}
+	wrapped.Remove = func(k K) bool {
+		rec.record(OpRemove, false, k)
+		return m.Remove(k)
+	}
+	if m.Each != nil {
+		wrapped.Each = func(fn func(key K, val V) bool) {
+			rec.w.WriteOp(OpEach)
+			m.Each(fn)
+		}
+	}
+	if m.Clear != nil {
+		wrapped.Clear = func() {
+			rec.w.WriteOp(OpClear)
+			m.Clear()
+		}
+	}
+	return wrapped, rec.w, nil
+}
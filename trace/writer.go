@@ -0,0 +1,163 @@
+package trace
+
+import (
+	"bufio"
+	"encoding/binary"
+	"io"
+	"math"
+	"os"
+	"sync"
+	"time"
+)
+
+// Writer writes a trace. It is safe for concurrent use.
+type Writer struct {
+	mu     sync.Mutex
+	w      *bufio.Writer
+	closer io.Closer
+	header Header
+	last   time.Time
+	now    func() time.Time
+	buf    [2*binary.MaxVarintLen64 + 9]byte
+	err    error
+}
+
+// NewWriter writes the header to w and returns a writer for the records.
+func NewWriter(w io.Writer, h Header) (*Writer, error) {
+	if h.Start.IsZero() {
+		h.Start = time.Now()
+	}
+	if h.SampleRate <= 0 || h.SampleRate > 1 {
+		h.SampleRate = 1
+	}
+	tw := &Writer{w: bufio.NewWriterSize(w, 64<<10), header: h, last: h.Start, now: time.Now}
+	if c, ok := w.(io.Closer); ok {
+		tw.closer = c
+	}
+
+	var hdr [len(magic) + 4 + 16]byte
+	n := copy(hdr[:], magic)
+	hdr[n] = version
+	hdr[n+1] = byte(h.KeyKind)
+	hdr[n+2] = byte(h.KeySize)
+	hdr[n+3] = byte(h.Encoding)
+	binary.LittleEndian.PutUint64(hdr[n+4:], math.Float64bits(h.SampleRate))
+	binary.LittleEndian.PutUint64(hdr[n+12:], uint64(h.Start.UnixNano()))
+	if _, err := tw.w.Write(hdr[:]); err != nil {
+		return nil, err
+	}
+	return tw, nil
+}
+
+// Create creates the trace file at path.
+func Create(path string, h Header) (*Writer, error) {
+	f, err := os.Create(path)
+	if err != nil {
+		return nil, err
+	}
+	w, err := NewWriter(f, h)
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+	return w, nil
+}
+
+// Header returns the header of the trace.
+func (w *Writer) Header() Header {
+	return w.header
+}
+
+// begin encodes the operation and the time delta into the buffer, w.mu must be held.
+func (w *Writer) begin(op Op, found bool) int {
+	now := w.now()
+	delta := now.Sub(w.last)
+	if delta < 0 {
+		delta = 0
+	}
+	w.last = now
+	b := byte(op)
+	if found {
+		b |= flagFound
+	}
+	w.buf[0] = b
+	return 1 + binary.PutUvarint(w.buf[1:], uint64(delta))
+}
+
+func (w *Writer) flushRecord(n int, data string) {
+	if w.err != nil {
+		return
+	}
+	if _, err := w.w.Write(w.buf[:n]); err != nil {
+		w.err = err
+		return
+	}
+	if data != "" {
+		if _, err := w.w.WriteString(data); err != nil {
+			w.err = err
+		}
+	}
+}
+
+// WriteOp records an operation without key, e.g. Each.
+func (w *Writer) WriteOp(op Op) {
+	w.mu.Lock()
+	n := w.begin(op, false)
+	w.flushRecord(n, "")
+	w.mu.Unlock()
+}
+
+// WriteInt records an operation with a raw integer key.
+func (w *Writer) WriteInt(op Op, found bool, key uint64) {
+	w.mu.Lock()
+	n := w.begin(op, found)
+	n += binary.PutUvarint(w.buf[n:], key)
+	w.flushRecord(n, "")
+	w.mu.Unlock()
+}
+
+// WriteString records an operation with a raw string key.
+func (w *Writer) WriteString(op Op, found bool, key string) {
+	w.mu.Lock()
+	n := w.begin(op, found)
+	n += binary.PutUvarint(w.buf[n:], uint64(len(key)))
+	w.flushRecord(n, key)
+	w.mu.Unlock()
+}
+
+// WriteHash records an operation with a hashed key. The length is only stored for string keys.
+func (w *Writer) WriteHash(op Op, found bool, hash uint64, length int) {
+	w.mu.Lock()
+	n := w.begin(op, found)
+	binary.LittleEndian.PutUint64(w.buf[n:], hash)
+	n += 8
+	if w.header.KeyKind == KindString {
+		n += binary.PutUvarint(w.buf[n:], uint64(length))
+	}
+	w.flushRecord(n, "")
+	w.mu.Unlock()
+}
+
+// Err returns the first write error.
+func (w *Writer) Err() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.err
+}
+
+// Close flushes the buffered records and closes the underlying writer, if it is an io.Closer.
+func (w *Writer) Close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	err := w.err
+	if ferr := w.w.Flush(); err == nil {
+		err = ferr
+	}
+	if w.closer != nil {
+		if cerr := w.closer.Close(); err == nil {
+			err = cerr
+		}
+		w.closer = nil
+	}
+	return err
+}
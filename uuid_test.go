@@ -122,7 +122,7 @@ func BenchmarkUUIDRandomReads(b *testing.B) {
 func BenchmarkUUIDReadsMisses(b *testing.B) {
 	for _, r := range getRanges() {
 		arr := genUUIDArray(r)
-		other := genUUIDArray(r)
+		other := genDifferentUUIDArray(arr)
 		for _, mapName := range getMapNames() {
 			b.Run(fmt.Sprintf("%s-%d", mapName, r), func(b *testing.B) {
 				load := float32(-1.0)
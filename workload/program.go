@@ -0,0 +1,274 @@
+package workload
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"sort"
+
+	"github.com/EinfachAndy/hashmaps"
+)
+
+type opKind uint8
+
+const (
+	kindRead opKind = iota
+	kindMiss
+	kindInsert
+	kindDelete
+	kindIterate
+)
+
+var kinds = map[string]opKind{
+	OpRead:    kindRead,
+	OpMiss:    kindMiss,
+	OpInsert:  kindInsert,
+	OpDelete:  kindDelete,
+	OpIterate: kindIterate,
+}
+
+type op[K comparable] struct {
+	kind opKind
+	key  K
+}
+
+type compiledPhase[K comparable] struct {
+	phase *Phase
+	ops   []op[K]
+}
+
+// Program is a spec compiled for a key type and prefill size. All operations and
+// keys are precomputed, so that the timed phases only execute the map operations.
+type Program[K comparable] struct {
+	spec    *Spec
+	prefill []K
+	phases  []compiledPhase[K]
+}
+
+// Timer controls the time measurement, it is implemented by *testing.B.
+type Timer interface {
+	StartTimer()
+	StopTimer()
+}
+
+// PhaseResult contains the statistics of an executed phase.
+type PhaseResult struct {
+	Name  string
+	Timed bool
+	Ops   int
+	Reads int
+	Hits  int
+	// Size is the number of elements after the phase, -1 if the map does not support Size.
+	Size int
+}
+
+// HitRate returns the ratio of successful reads.
+func (r *PhaseResult) HitRate() float64 {
+	if r.Reads == 0 {
+		return math.NaN()
+	}
+	return float64(r.Hits) / float64(r.Reads)
+}
+
+// counts distributes n operations exactly according to the mix of the phase.
+func (p *Phase) counts(n int) map[opKind]int {
+	counts := make(map[opKind]int)
+	ops := p.sortedOps()
+	type rest struct {
+		kind opKind
+		frac float64
+	}
+	var rests []rest
+	total := 0
+	for _, name := range ops {
+		exact := float64(n) * p.ratio(name)
+		c := int(math.Floor(exact))
+		counts[kinds[name]] = c
+		total += c
+		rests = append(rests, rest{kinds[name], exact - float64(c)})
+	}
+	sort.SliceStable(rests, func(i, j int) bool { return rests[i].frac > rests[j].frac })
+	for i := 0; total < n; i++ {
+		counts[rests[i%len(rests)].kind]++
+		total++
+	}
+	return counts
+}
+
+// Compile precomputes the operations of the spec. The keys must contain at least
+// KeyCount distinct keys. The misses must not be contained in keys, they are reused
+// if there are less than MissCount of them.
+func Compile[K comparable](spec *Spec, prefill int, keys, misses []K, rng *rand.Rand) (*Program[K], error) {
+	if len(keys) < spec.KeyCount(prefill) {
+		return nil, fmt.Errorf("%d keys required, but only %d provided", spec.KeyCount(prefill), len(keys))
+	}
+	if len(misses) == 0 {
+		return nil, fmt.Errorf("at least one miss key required")
+	}
+
+	p := &Program[K]{spec: spec, prefill: keys[:prefill]}
+	// the simulation tracks the inserted keys as index into keys,
+	// live contains the existing keys and pos their position in live
+	next := prefill
+	live := make([]int, prefill, len(keys))
+	pos := make(map[int]int, len(keys))
+	for i := range live {
+		live[i] = i
+		pos[i] = i
+	}
+	missIdx := 0
+
+	for i := range spec.Phases {
+		phase := &spec.Phases[i]
+		n := phase.ops(prefill)
+		seq := make([]opKind, 0, n)
+		for kind, c := range phase.counts(n) {
+			for j := 0; j < c; j++ {
+				seq = append(seq, kind)
+			}
+		}
+		sort.Slice(seq, func(a, b int) bool { return seq[a] < seq[b] })
+		rng.Shuffle(len(seq), func(a, b int) { seq[a], seq[b] = seq[b], seq[a] })
+
+		var zipf *rand.Zipf
+		if phase.Distribution == Zipf && len(keys) > 1 {
+			zipf = rand.NewZipf(rng, phase.ZipfS, 1, uint64(len(keys)-1))
+		}
+		counter := 0
+		// pick selects an index in [0, n) according to the access distribution
+		pick := func(n int) int {
+			switch {
+			case zipf != nil:
+				return int(zipf.Uint64() % uint64(n))
+			case phase.Distribution == Sequential:
+				counter++
+				return (counter - 1) % n
+			default:
+				return rng.Intn(n)
+			}
+		}
+
+		cp := compiledPhase[K]{phase: phase, ops: make([]op[K], 0, n)}
+		for _, kind := range seq {
+			switch kind {
+			case kindRead:
+				if next == 0 {
+					kind = kindMiss
+					break
+				}
+				// reads access all keys ever inserted, deleted keys lead to misses
+				cp.ops = append(cp.ops, op[K]{kindRead, keys[pick(next)]})
+				continue
+			case kindInsert:
+				if next >= len(keys) {
+					return nil, fmt.Errorf("phase %q: not enough keys for inserts", phase.Name)
+				}
+				pos[next] = len(live)
+				live = append(live, next)
+				cp.ops = append(cp.ops, op[K]{kindInsert, keys[next]})
+				next++
+				continue
+			case kindDelete:
+				if len(live) == 0 {
+					kind = kindMiss
+					break
+				}
+				j := pick(len(live))
+				idx := live[j]
+				last := live[len(live)-1]
+				live[j] = last
+				pos[last] = j
+				live = live[:len(live)-1]
+				delete(pos, idx)
+				cp.ops = append(cp.ops, op[K]{kindDelete, keys[idx]})
+				continue
+			case kindIterate:
+				cp.ops = append(cp.ops, op[K]{kind: kindIterate})
+				continue
+			}
+			// kindMiss or a fallback of an operation on an empty map
+			cp.ops = append(cp.ops, op[K]{kindRead, misses[missIdx%len(misses)]})
+			missIdx++
+		}
+		p.phases = append(p.phases, cp)
+	}
+	return p, nil
+}
+
+// TimedOps returns the number of operations of all timed phases.
+func (p *Program[K]) TimedOps() int {
+	n := 0
+	for _, cp := range p.phases {
+		if cp.phase.Timed {
+			n += len(cp.ops)
+		}
+	}
+	return n
+}
+
+func ignore[K comparable, V any](key K, val V) bool {
+	return false
+}
+
+// Run prefills the map and executes all phases. The timer must be stopped, it is only
+// started during the timed phases.
+func (p *Program[K]) Run(m hashmaps.IHashMap[K, uint64], t Timer) []PhaseResult {
+	if p.spec.Reserve && m.Reserve != nil {
+		m.Reserve(uintptr(len(p.prefill)))
+	}
+	for _, k := range p.prefill {
+		m.Put(k, 1)
+	}
+
+	results := make([]PhaseResult, 0, len(p.phases))
+	for _, cp := range p.phases {
+		r := PhaseResult{Name: cp.phase.Name, Timed: cp.phase.Timed, Ops: len(cp.ops), Size: -1}
+		if cp.phase.Timed {
+			t.StartTimer()
+		}
+		for _, o := range cp.ops {
+			switch o.kind {
+			case kindRead:
+				r.Reads++
+				if _, found := m.Get(o.key); found {
+					r.Hits++
+				}
+			case kindInsert:
+				m.Put(o.key, 1)
+			case kindDelete:
+				m.Remove(o.key)
+			case kindIterate:
+				m.Each(ignore[K, uint64])
+			}
+		}
+		if cp.phase.Timed {
+			t.StopTimer()
+		}
+		if m.Size != nil {
+			r.Size = int(m.Size())
+		}
+		results = append(results, r)
+	}
+	return results
+}
+
+// Check verifies the assertions of the spec against the results of Run.
+func (p *Program[K]) Check(results []PhaseResult) error {
+	for _, a := range p.spec.Assertions {
+		for _, r := range results {
+			if r.Name != a.Phase {
+				continue
+			}
+			if a.MinHitRate != nil && !(r.HitRate() >= *a.MinHitRate) {
+				return fmt.Errorf("phase %q: hit rate %.4f below %.4f", r.Name, r.HitRate(), *a.MinHitRate)
+			}
+			if a.MaxHitRate != nil && !(r.HitRate() <= *a.MaxHitRate) {
+				return fmt.Errorf("phase %q: hit rate %.4f above %.4f", r.Name, r.HitRate(), *a.MaxHitRate)
+			}
+			if a.Size != nil && r.Size >= 0 && r.Size != *a.Size {
+				return fmt.Errorf("phase %q: size %d, expected %d", r.Name, r.Size, *a.Size)
+			}
+		}
+	}
+	return nil
+}
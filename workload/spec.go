@@ -0,0 +1,252 @@
+// Package workload interprets declarative benchmark scenarios, which are described in JSON spec files.
+//
+// Example of a spec, which prefills one million UUIDs untimed and then executes
+// ten million timed operations with 95% zipf distributed reads and 5% inserts:
+//
+//	{
+//	  "name": "ZipfReads",
+//	  "keyType": "UUID",
+//	  "prefill": 1000000,
+//	  "phases": [
+//	    {"name": "main", "ops": 10000000, "timed": true,
+//	     "mix": {"read": 0.95, "insert": 0.05}, "distribution": "zipf"}
+//	  ],
+//	  "assertions": [{"phase": "main", "minHitRate": 0.99}]
+//	}
+package workload
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Operations of a phase mix.
+const (
+	OpRead    = "read"
+	OpMiss    = "miss"
+	OpInsert  = "insert"
+	OpDelete  = "delete"
+	OpIterate = "iterate"
+)
+
+// Access distributions of the existing keys.
+const (
+	Uniform    = "uniform"
+	Zipf       = "zipf"
+	Sequential = "sequential"
+)
+
+var operations = []string{OpRead, OpMiss, OpInsert, OpDelete, OpIterate}
+
+// generators are the key generators of each key type, the first one is the default.
+var generators = map[string][]string{
+	"U32":  {"random", "shuffled"},
+	"U64":  {"random", "shuffled"},
+	"UUID": {"uuid"},
+}
+
+// Phase is a sequence of operations with the given mix.
+type Phase struct {
+	Name string `json:"name"`
+	// Ops is the number of operations.
+	Ops int `json:"ops,omitempty"`
+	// OpsPerKey is the number of operations relative to the prefill size, used if Ops is 0.
+	OpsPerKey float64 `json:"opsPerKey,omitempty"`
+	// Timed defines whether the phase is measured.
+	Timed bool `json:"timed"`
+	// Mix are the ratios of the operations read, miss, insert, delete and iterate.
+	Mix map[string]float64 `json:"mix"`
+	// Distribution is the access distribution of reads and deletes: uniform (default), zipf or sequential.
+	Distribution string `json:"distribution,omitempty"`
+	// ZipfS is the exponent of the zipf distribution, must be greater than 1 (default 1.1).
+	ZipfS float64 `json:"zipfS,omitempty"`
+}
+
+// Assertion is checked after the execution of a phase.
+type Assertion struct {
+	Phase string `json:"phase"`
+	// MinHitRate is the minimal ratio of successful reads.
+	MinHitRate *float64 `json:"minHitRate,omitempty"`
+	// MaxHitRate is the maximal ratio of successful reads.
+	MaxHitRate *float64 `json:"maxHitRate,omitempty"`
+	// Size is the expected number of elements after the phase, if the map supports Size.
+	Size *int `json:"size,omitempty"`
+}
+
+// Spec describes a benchmark scenario.
+type Spec struct {
+	// Name is the name of the scenario used in the benchmark name.
+	Name string `json:"name"`
+	// KeyType is the key type: U32, U64 or UUID.
+	KeyType string `json:"keyType"`
+	// Generator is the key generator: random (default) or shuffled for integer keys, uuid for UUID keys.
+	Generator string `json:"generator,omitempty"`
+	// Prefill is the number of keys inserted before the first phase.
+	// If it is 0, the configured sizes of the benchmark are used.
+	Prefill int `json:"prefill,omitempty"`
+	// Reserve reserves the capacity of the prefill size beforehand.
+	Reserve bool `json:"reserve,omitempty"`
+	// Phases are executed in order.
+	Phases     []Phase     `json:"phases"`
+	Assertions []Assertion `json:"assertions,omitempty"`
+}
+
+var namePattern = regexp.MustCompile(`^[A-Za-z0-9_]+$`)
+
+// Load reads and validates a spec file.
+func Load(path string) (*Spec, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	spec := &Spec{}
+	dec := json.NewDecoder(strings.NewReader(string(data)))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(spec); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	if err := spec.Validate(); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return spec, nil
+}
+
+// Validate checks the spec for invalid values and sets the defaults.
+func (s *Spec) Validate() error {
+	if !namePattern.MatchString(s.Name) {
+		return fmt.Errorf("invalid name %q, only letters, digits and _ are allowed", s.Name)
+	}
+	available, ok := generators[s.KeyType]
+	if !ok {
+		return fmt.Errorf("unknown key type %q, available: U32 U64 UUID", s.KeyType)
+	}
+	if s.Generator == "" {
+		s.Generator = available[0]
+	}
+	if !contains(available, s.Generator) {
+		return fmt.Errorf("unknown generator %q of %s keys, available: %s", s.Generator, s.KeyType, strings.Join(available, " "))
+	}
+	if s.Prefill < 0 {
+		return fmt.Errorf("negative prefill %d", s.Prefill)
+	}
+	if len(s.Phases) == 0 {
+		return fmt.Errorf("no phases defined")
+	}
+	names := make(map[string]bool)
+	for i := range s.Phases {
+		p := &s.Phases[i]
+		if p.Name == "" {
+			p.Name = fmt.Sprintf("phase%d", i+1)
+		}
+		if names[p.Name] {
+			return fmt.Errorf("duplicate phase %q", p.Name)
+		}
+		names[p.Name] = true
+		if p.Ops < 0 || p.OpsPerKey < 0 || (p.Ops == 0 && p.OpsPerKey == 0) {
+			return fmt.Errorf("phase %q: ops or opsPerKey must be positive", p.Name)
+		}
+		sum := 0.0
+		for op, ratio := range p.Mix {
+			if !contains(operations, op) {
+				return fmt.Errorf("phase %q: unknown operation %q, available: %s", p.Name, op, strings.Join(operations, " "))
+			}
+			if ratio < 0 {
+				return fmt.Errorf("phase %q: negative ratio of %s", p.Name, op)
+			}
+			sum += ratio
+		}
+		if sum <= 0 {
+			return fmt.Errorf("phase %q: empty mix", p.Name)
+		}
+		switch p.Distribution {
+		case "":
+			p.Distribution = Uniform
+		case Uniform, Sequential:
+		case Zipf:
+			if p.ZipfS == 0 {
+				p.ZipfS = 1.1
+			}
+			if p.ZipfS <= 1 {
+				return fmt.Errorf("phase %q: zipfS must be greater than 1", p.Name)
+			}
+		default:
+			return fmt.Errorf("phase %q: unknown distribution %q, available: uniform zipf sequential", p.Name, p.Distribution)
+		}
+	}
+	for _, a := range s.Assertions {
+		if !names[a.Phase] {
+			return fmt.Errorf("assertion references unknown phase %q", a.Phase)
+		}
+	}
+	return nil
+}
+
+// ops returns the number of operations of the phase for the prefill size.
+func (p *Phase) ops(prefill int) int {
+	if p.Ops > 0 {
+		return p.Ops
+	}
+	return int(math.Ceil(p.OpsPerKey * float64(prefill)))
+}
+
+// KeyCount returns an upper bound of the distinct keys inserted for the prefill size.
+func (s *Spec) KeyCount(prefill int) int {
+	n := prefill
+	for i := range s.Phases {
+		p := &s.Phases[i]
+		n += int(math.Ceil(float64(p.ops(prefill)) * p.ratio(OpInsert)))
+	}
+	return n
+}
+
+// MissCount returns the number of distinct keys used for misses, at least one.
+func (s *Spec) MissCount(prefill int) int {
+	n := 0
+	for i := range s.Phases {
+		p := &s.Phases[i]
+		n += int(math.Ceil(float64(p.ops(prefill)) * p.ratio(OpMiss)))
+	}
+	// misses are reused, if there are many of them
+	if n > 1<<20 {
+		n = 1 << 20
+	}
+	if n < 1 {
+		n = 1
+	}
+	return n
+}
+
+// ratio returns the normalized ratio of the operation.
+func (p *Phase) ratio(op string) float64 {
+	sum := 0.0
+	for _, r := range p.Mix {
+		sum += r
+	}
+	return p.Mix[op] / sum
+}
+
+func contains(list []string, s string) bool {
+	for _, x := range list {
+		if x == s {
+			return true
+		}
+	}
+	return false
+}
+
+// sortedOps returns the operations of the mix in a stable order.
+func (p *Phase) sortedOps() []string {
+	ops := make([]string, 0, len(p.Mix))
+	for op, r := range p.Mix {
+		if r > 0 {
+			ops = append(ops, op)
+		}
+	}
+	sort.Strings(ops)
+	return ops
+}
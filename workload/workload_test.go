@@ -0,0 +1,157 @@
+package workload
+
+import (
+	"math/rand"
+	"strings"
+	"testing"
+
+	"github.com/EinfachAndy/hashmaps"
+)
+
+// stdMap wraps a builtin map.
+func stdMap() hashmaps.IHashMap[int, uint64] {
+	m := make(map[int]uint64)
+	return hashmaps.IHashMap[int, uint64]{
+		Put: func(k int, v uint64) bool {
+			m[k] = v
+			return false
+		},
+		Get: func(k int) (uint64, bool) {
+			v, ok := m[k]
+			return v, ok
+		},
+		Remove: func(k int) bool {
+			_, ok := m[k]
+			delete(m, k)
+			return ok
+		},
+		Size: func() int { return len(m) },
+		Each: func(fn func(int, uint64) bool) {
+			for k, v := range m {
+				if fn(k, v) {
+					return
+				}
+			}
+		},
+	}
+}
+
+type noTimer struct{}
+
+func (noTimer) StartTimer() {}
+func (noTimer) StopTimer()  {}
+
+func TestValidate(t *testing.T) {
+	phase := func(mix map[string]float64) Phase { return Phase{Name: "main", Ops: 10, Mix: mix} }
+	read := map[string]float64{OpRead: 1}
+	tests := []struct {
+		name string
+		spec Spec
+		want string
+	}{
+		{"valid", Spec{Name: "Reads", KeyType: "U64", Phases: []Phase{phase(read)}}, ""},
+		{"invalid name", Spec{Name: "Zipf Reads", KeyType: "U64", Phases: []Phase{phase(read)}}, "invalid name"},
+		{"unknown key type", Spec{Name: "Reads", KeyType: "U16", Phases: []Phase{phase(read)}}, "unknown key type"},
+		{"shuffled generator", Spec{Name: "Reads", KeyType: "U32", Generator: "shuffled", Phases: []Phase{phase(read)}}, ""},
+		{"unknown generator", Spec{Name: "Reads", KeyType: "U64", Generator: "zipf", Phases: []Phase{phase(read)}}, "unknown generator"},
+		{"integer generator of UUID keys", Spec{Name: "Reads", KeyType: "UUID", Generator: "shuffled", Phases: []Phase{phase(read)}}, "unknown generator"},
+		{"no phases", Spec{Name: "Reads", KeyType: "U64"}, "no phases"},
+		{"duplicate phase", Spec{Name: "Reads", KeyType: "U64", Phases: []Phase{phase(read), phase(read)}}, "duplicate phase"},
+		{"no ops", Spec{Name: "Reads", KeyType: "U64", Phases: []Phase{{Mix: read}}}, "must be positive"},
+		{"unknown operation", Spec{Name: "Reads", KeyType: "U64", Phases: []Phase{phase(map[string]float64{"scan": 1})}}, "unknown operation"},
+		{"empty mix", Spec{Name: "Reads", KeyType: "U64", Phases: []Phase{phase(map[string]float64{OpRead: 0})}}, "empty mix"},
+		{"zipf exponent", Spec{Name: "Reads", KeyType: "U64", Phases: []Phase{{Name: "main", Ops: 1, Mix: read, Distribution: Zipf, ZipfS: 0.5}}}, "zipfS"},
+		{"unknown distribution", Spec{Name: "Reads", KeyType: "U64", Phases: []Phase{{Name: "main", Ops: 1, Mix: read, Distribution: "normal"}}}, "unknown distribution"},
+		{"unknown assertion phase", Spec{Name: "Reads", KeyType: "U64", Phases: []Phase{phase(read)}, Assertions: []Assertion{{Phase: "warmup"}}}, "unknown phase"},
+	}
+	for _, tt := range tests {
+		err := tt.spec.Validate()
+		if (tt.want == "") != (err == nil) || (err != nil && !strings.Contains(err.Error(), tt.want)) {
+			t.Errorf("%s: error = %v, want %q", tt.name, err, tt.want)
+		}
+	}
+}
+
+func TestCounts(t *testing.T) {
+	tests := []struct {
+		mix  map[string]float64
+		n    int
+		want map[opKind]int
+	}{
+		{map[string]float64{OpRead: 1}, 10, map[opKind]int{kindRead: 10}},
+		{map[string]float64{OpRead: 3, OpInsert: 1}, 8, map[opKind]int{kindRead: 6, kindInsert: 2}},
+		{map[string]float64{OpRead: 1, OpMiss: 1, OpDelete: 1}, 10, map[opKind]int{kindRead: 3, kindMiss: 3, kindDelete: 4}},
+		{map[string]float64{OpRead: 0.97, OpInsert: 0.03}, 10, map[opKind]int{kindRead: 10, kindInsert: 0}},
+	}
+	for _, tt := range tests {
+		p := &Phase{Mix: tt.mix}
+		got := p.counts(tt.n)
+		for kind, want := range tt.want {
+			if got[kind] != want {
+				t.Errorf("counts(%v, %d) = %v, want %v", tt.mix, tt.n, got, tt.want)
+				break
+			}
+		}
+	}
+}
+
+func TestProgram(t *testing.T) {
+	minHits, maxHits, size := 0.99, 0.5, 150
+	spec := &Spec{
+		Name:    "Churn",
+		KeyType: "U64",
+		Phases: []Phase{
+			{Name: "grow", Ops: 50, Mix: map[string]float64{OpInsert: 1}},
+			{Name: "main", OpsPerKey: 2, Timed: true, Mix: map[string]float64{OpRead: 1, OpMiss: 1}},
+			{Name: "scan", Ops: 1, Timed: true, Mix: map[string]float64{OpIterate: 1}},
+		},
+	}
+	if err := spec.Validate(); err != nil {
+		t.Fatal(err)
+	}
+	const prefill = 100
+	keys := make([]int, spec.KeyCount(prefill))
+	for i := range keys {
+		keys[i] = i
+	}
+	misses := []int{-1, -2, -3}
+	p, err := Compile(spec, prefill, keys, misses, rand.New(rand.NewSource(1)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := p.TimedOps(); got != 2*prefill+1 {
+		t.Errorf("timed ops = %d, want %d", got, 2*prefill+1)
+	}
+	results := p.Run(stdMap(), noTimer{})
+	if len(results) != 3 || results[0].Size != size || results[1].Reads != 2*prefill || results[1].HitRate() != 0.5 {
+		t.Fatalf("unexpected results: %+v", results)
+	}
+
+	tests := []struct {
+		name      string
+		assertion Assertion
+		want      string
+	}{
+		{"min hit rate", Assertion{Phase: "main", MinHitRate: &maxHits}, ""},
+		{"min hit rate violated", Assertion{Phase: "main", MinHitRate: &minHits}, "below"},
+		{"max hit rate", Assertion{Phase: "main", MaxHitRate: &maxHits}, ""},
+		{"max hit rate violated", Assertion{Phase: "main", MaxHitRate: new(float64)}, "above"},
+		{"size", Assertion{Phase: "grow", Size: &size}, ""},
+		{"size violated", Assertion{Phase: "grow", Size: new(int)}, "size 150"},
+		{"no reads", Assertion{Phase: "scan", MinHitRate: new(float64)}, "hit rate NaN"},
+	}
+	for _, tt := range tests {
+		spec.Assertions = []Assertion{tt.assertion}
+		err := p.Check(results)
+		if (tt.want == "") != (err == nil) || (err != nil && !strings.Contains(err.Error(), tt.want)) {
+			t.Errorf("%s: error = %v, want %q", tt.name, err, tt.want)
+		}
+	}
+
+	if _, err := Compile(spec, prefill, keys[:prefill], misses, rand.New(rand.NewSource(1))); err == nil {
+		t.Error("compiling with too few keys: expected an error")
+	}
+	if _, err := Compile(spec, prefill, keys, nil, rand.New(rand.NewSource(1))); err == nil {
+		t.Error("compiling without misses: expected an error")
+	}
+}